@@ -0,0 +1,304 @@
+package govaluate
+
+import (
+	"fmt"
+	"time"
+)
+
+/*
+	The date formats which string literals are tried as, unless an Env is given different ones.
+*/
+var DefaultDateFormats = []string{
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+	time.Kitchen,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02",                         // RFC 3339
+	"2006-01-02 15:04",                   // RFC 3339 with minutes
+	"2006-01-02 15:04:05",                // RFC 3339 with seconds
+	"2006-01-02 15:04:05-07:00",          // RFC 3339 with seconds and timezone
+	"2006-01-02T15Z0700",                 // ISO8601 with hour
+	"2006-01-02T15:04Z0700",              // ISO8601 with minutes
+	"2006-01-02T15:04:05Z0700",           // ISO8601 with seconds
+	"2006-01-02T15:04:05.999999999Z0700", // ISO8601 with nanoseconds
+}
+
+/*
+	Env holds everything that affects how expressions are compiled, so that it can be set up once and used for many expressions.
+	Use `NewEnv` to get an Env with the same defaults that `NewEvaluableExpression` uses, then change what's needed.
+	An Env must not be modified while it's compiling expressions.
+*/
+type Env struct {
+
+	/*
+		Functions which expressions may call, in addition to the builtins (which these take priority over).
+	*/
+	Functions map[string]ExpressionFunction
+
+	/*
+		The names of those `Functions` which are pure; which have no side effects, and always return the same result for the same arguments.
+		Calls to pure functions which are repeated within an expression are only made once per evaluation, like any other part of it
+		which is repeated. Other functions are called every time. The builtins are all pure, unless one is replaced in `Functions`.
+	*/
+	PureFunctions map[string]bool
+
+	/*
+		The derivatives of those `Functions` which take one number, used by `Derivative`. Each is written as an expression
+		of the function's argument, which is named `x`; if `sin` and `cos` are both functions, the derivative of `sin` is `cos(x)`.
+	*/
+	Derivatives map[string]string
+
+	/*
+		Values which never change, which expressions can refer to by name just like parameters.
+		Constants are substituted into expressions when they're compiled, so operations on them are done once, at compile time,
+		and they aren't reported by `Vars()`. Constants must be numbers, strings, bools, times, or nil.
+	*/
+	Constants map[string]interface{}
+
+	/*
+		Whether or not compiled expressions check types when evaluated. See `EvaluableExpression.ChecksTypes`.
+	*/
+	ChecksTypes bool
+
+	/*
+		The hooks which compiled expressions call when evaluated, if any. See `EvaluableExpression.Hooks`.
+	*/
+	Hooks EvaluationHooks
+
+	/*
+		The format used by compiled expressions to output dates. See `EvaluableExpression.QueryDateFormat`.
+	*/
+	QueryDateFormat string
+
+	/*
+		Whether or not string literals are tried as dates. If false, they're always strings.
+	*/
+	ParseDates bool
+
+	/*
+		The formats which string literals are tried as, in order, when `ParseDates` is true.
+	*/
+	DateFormats []string
+
+	/*
+		The location which dates are in, if their format doesn't specify one. If nil, `time.Local` is used.
+	*/
+	DateLocation *time.Location
+
+	/*
+		The most characters an expression may have. Zero means there's no limit.
+	*/
+	MaxLength int
+
+	/*
+		The most tokens an expression may have. Zero means there's no limit.
+	*/
+	MaxTokens int
+
+	/*
+		The deepest that parenthesis may be nested within an expression. Zero means there's no limit.
+	*/
+	MaxDepth int
+}
+
+/*
+	Returns a new Env with the defaults used by `NewEvaluableExpression`; type checking, ISO8601 query dates,
+	the default date formats in the local time zone, and no limits.
+*/
+func NewEnv() *Env {
+
+	dateFormats := make([]string, len(DefaultDateFormats))
+	copy(dateFormats, DefaultDateFormats)
+
+	return &Env{
+		Functions:       make(map[string]ExpressionFunction),
+		PureFunctions:   make(map[string]bool),
+		Derivatives:     make(map[string]string),
+		Constants:       make(map[string]interface{}),
+		ChecksTypes:     true,
+		QueryDateFormat: isoDateFormat,
+		ParseDates:      true,
+		DateFormats:     dateFormats,
+		DateLocation:    time.Local,
+	}
+}
+
+/*
+	Parses the given [expression] string into an EvaluableExpression, using the functions and options of this Env.
+	Returns an error if the expression has invalid syntax, or exceeds the limits of this Env.
+*/
+func (env *Env) Compile(expression string) (*EvaluableExpression, error) {
+
+	if env.MaxLength > 0 && len([]rune(expression)) > env.MaxLength {
+		return nil, fmt.Errorf("Expression is longer than the limit of %d characters", env.MaxLength)
+	}
+
+	// also checks balance and syntax, since it knows where each token came from.
+	tokens, functionNames, err := parseTokens(env, expression)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := env.compile(tokens, functionNames)
+	if err != nil {
+		return nil, err
+	}
+
+	ret.inputExpression = expression
+	return ret, nil
+}
+
+/*
+	Same as `Compile`, except that an already-tokenized expression is given. See `NewEvaluableExpressionFromTokens`.
+*/
+func (env *Env) CompileTokens(tokens []ExpressionToken) (*EvaluableExpression, error) {
+	return env.compileTokens(tokens, nil)
+}
+
+/*
+	Same as `CompileTokens`, but with the names of the functions used by the [tokens], by the index of their token.
+*/
+func (env *Env) compileTokens(tokens []ExpressionToken, functionNames map[int]string) (*EvaluableExpression, error) {
+
+	_, err := checkBalance(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = checkExpressionSyntax(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return env.compile(tokens, functionNames)
+}
+
+func (env *Env) compile(tokens []ExpressionToken, functionNames map[int]string) (*EvaluableExpression, error) {
+
+	var ret *EvaluableExpression
+	var err error
+
+	err = env.checkLimits(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	ret = new(EvaluableExpression)
+	ret.QueryDateFormat = env.QueryDateFormat
+	ret.ChecksTypes = env.ChecksTypes
+	ret.Hooks = env.Hooks
+
+	ret.tokens, err = optimizeTokens(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	ret.evaluationStages, err = planStages(ret.tokens, functionNames)
+	if err != nil {
+		return nil, err
+	}
+
+	ret.functionNames = functionNames
+	ret.sharedSlots = shareSubexpressions([]*evaluationStage{ret.evaluationStages}, 0, env.isPure)
+	return ret, nil
+}
+
+/*
+	Whether the function of the given [name] is pure, so that calls to it can be shared.
+*/
+func (env *Env) isPure(name string) bool {
+
+	if _, found := env.Functions[name]; found {
+		return env.PureFunctions[name]
+	}
+
+	_, found := builtinFunctions[name]
+	return found
+}
+
+func (env *Env) checkLimits(tokens []ExpressionToken) error {
+
+	var depth, maxDepth int
+
+	if env.MaxTokens > 0 && len(tokens) > env.MaxTokens {
+		return fmt.Errorf("Expression has %d tokens, more than the limit of %d", len(tokens), env.MaxTokens)
+	}
+
+	if env.MaxDepth <= 0 {
+		return nil
+	}
+
+	for _, token := range tokens {
+
+		switch token.Kind {
+		case CLAUSE:
+			depth++
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+		case CLAUSE_CLOSE:
+			depth--
+		}
+	}
+
+	if maxDepth > env.MaxDepth {
+		return fmt.Errorf("Expression nests parenthesis deeper than the limit of %d", env.MaxDepth)
+	}
+	return nil
+}
+
+/*
+	Finds the constant of the given [name], returning the kind and value of the literal token which should replace it.
+	Returns false if there's no such constant.
+*/
+func (env *Env) findConstant(name string) (TokenKind, interface{}, bool, error) {
+
+	value, found := env.Constants[name]
+	if !found {
+		return UNKNOWN, nil, false, nil
+	}
+
+	value = castToFloat64(value)
+
+	switch value.(type) {
+	case nil:
+		return NULL, nil, true, nil
+	case float64:
+		return NUMERIC, value, true, nil
+	case string:
+		return STRING, value, true, nil
+	case bool:
+		return BOOLEAN, value, true, nil
+	case time.Time:
+		return TIME, value, true, nil
+	}
+
+	return UNKNOWN, nil, false, fmt.Errorf("Constant '%s' is a %T, but constants can only be numbers, strings, bools, times, or nil", name, value)
+}
+
+/*
+	Tries each of this Env's date formats on the given [candidate], returning the Time of the first which applies,
+	otherwise returns false through the second return.
+*/
+func (env *Env) tryParseTime(candidate string) (time.Time, bool) {
+
+	if !env.ParseDates {
+		return time.Time{}, false
+	}
+
+	location := env.DateLocation
+	if location == nil {
+		location = time.Local
+	}
+
+	for _, format := range env.DateFormats {
+
+		ret, err := time.ParseInLocation(format, candidate, location)
+		if err == nil {
+			return ret, true
+		}
+	}
+	return time.Time{}, false
+}
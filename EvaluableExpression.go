@@ -33,9 +33,20 @@ type EvaluableExpression struct {
 	*/
 	ChecksTypes bool
 
+	/*
+		If not nil, these are called as the expression is evaluated. See `EvaluationHooks`.
+	*/
+	Hooks EvaluationHooks
+
 	tokens           []ExpressionToken
 	evaluationStages *evaluationStage
 	inputExpression  string
+
+	// the names of functions used by this expression, keyed by the index of their token.
+	functionNames map[int]string
+
+	// the number of results of repeated stages which are kept during each evaluation, so that they're only evaluated once.
+	sharedSlots int
 }
 
 /*
@@ -53,35 +64,7 @@ func NewEvaluableExpression(expression string) (*EvaluableExpression, error) {
 	This is useful in cases where you may be generating an expression automatically, or using some other parser (e.g., to parse from a query language)
 */
 func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpression, error) {
-
-	var ret *EvaluableExpression
-	var err error
-
-	ret = new(EvaluableExpression)
-	ret.QueryDateFormat = isoDateFormat
-
-	err = checkBalance(tokens)
-	if err != nil {
-		return nil, err
-	}
-
-	err = checkExpressionSyntax(tokens)
-	if err != nil {
-		return nil, err
-	}
-
-	ret.tokens, err = optimizeTokens(tokens)
-	if err != nil {
-		return nil, err
-	}
-
-	ret.evaluationStages, err = planStages(ret.tokens)
-	if err != nil {
-		return nil, err
-	}
-
-	ret.ChecksTypes = true
-	return ret, nil
+	return NewEnv().CompileTokens(tokens)
 }
 
 /*
@@ -90,40 +73,10 @@ func NewEvaluableExpressionFromTokens(tokens []ExpressionToken) (*EvaluableExpre
 */
 func NewEvaluableExpressionWithFunctions(expression string, functions map[string]ExpressionFunction) (*EvaluableExpression, error) {
 
-	var ret *EvaluableExpression
-	var err error
-
-	ret = new(EvaluableExpression)
-	ret.QueryDateFormat = isoDateFormat
-	ret.inputExpression = expression
-
-	ret.tokens, err = parseTokens(expression, functions)
-	if err != nil {
-		return nil, err
-	}
-
-	err = checkBalance(ret.tokens)
-	if err != nil {
-		return nil, err
-	}
-
-	err = checkExpressionSyntax(ret.tokens)
-	if err != nil {
-		return nil, err
-	}
-
-	ret.tokens, err = optimizeTokens(ret.tokens)
-	if err != nil {
-		return nil, err
-	}
-
-	ret.evaluationStages, err = planStages(ret.tokens)
-	if err != nil {
-		return nil, err
-	}
+	env := NewEnv()
+	env.Functions = functions
 
-	ret.ChecksTypes = true
-	return ret, nil
+	return env.Compile(expression)
 }
 
 /*
@@ -131,9 +84,9 @@ func NewEvaluableExpressionWithFunctions(expression string, functions map[string
 */
 func (expr EvaluableExpression) Evaluate(parameters map[string]interface{}) (interface{}, error) {
 	if parameters == nil {
-		return expr.Eval(nil)
+		return expr.eval(nil, nil)
 	}
-	return expr.Eval(MapParameters(parameters))
+	return expr.eval(MapParameters(parameters), nil)
 }
 
 /*
@@ -148,26 +101,110 @@ func (expr EvaluableExpression) Evaluate(parameters map[string]interface{}) (int
 	e.g., if the expression is "foo + 1" and parameters contains "foo" = 2, this will return 3.0
 */
 func (expr EvaluableExpression) Eval(parameters Parameters) (interface{}, error) {
+	return expr.eval(parameters, nil)
+}
+
+/*
+	Same as `Eval`, but uses the given [sanitized] wrapper for the parameters instead of allocating one,
+	so that it can be reused when evaluating many times. If it's nil, one is only allocated if there are parameters.
+*/
+func (expr *EvaluableExpression) eval(parameters Parameters, sanitized *sanitizedParameters) (interface{}, error) {
 	if expr.evaluationStages == nil {
 		return nil, nil
 	}
 
+	if scoped, ok := parameters.(evaluationScopedParameters); ok {
+		parameters = scoped.forEvaluation()
+	}
+
 	if parameters != nil {
-		parameters = &sanitizedParameters{parameters}
+		if sanitized == nil {
+			sanitized = new(sanitizedParameters)
+		}
+		sanitized.orig = parameters
+		parameters = sanitized
 	} else {
 		parameters = DUMMY_PARAMETERS
 	}
 
-	return expr.evaluateStage(expr.evaluationStages, parameters)
+	if expr.Hooks != nil {
+		parameters = hookedParameters{parameters, expr.Hooks}
+	}
+
+	if expr.sharedSlots > 0 {
+		parameters = newCachedParameters(parameters, expr.sharedSlots)
+	}
+
+	result, err := expr.evaluateStage(expr.evaluationStages, parameters)
+	if err != nil && expr.Hooks != nil {
+		expr.Hooks.Error(err)
+	}
+	return result, err
+}
+
+func (expr *EvaluableExpression) evaluateStage(stage *evaluationStage, parameters Parameters) (interface{}, error) {
+	return expr.computeStage(stage, parameters, false)
+}
+
+/*
+	Evaluates a stage which has hooks to call, or whose result may be shared.
+*/
+func (expr *EvaluableExpression) evaluateObservedStage(stage *evaluationStage, parameters Parameters) (interface{}, error) {
+
+	if expr.Hooks != nil {
+		return expr.hookStage(stage, parameters)
+	}
+	return expr.evaluateSharedStage(stage, parameters)
+}
+
+func (expr *EvaluableExpression) evaluateSharedStage(stage *evaluationStage, parameters Parameters) (interface{}, error) {
+
+	// stages which are shared are only evaluated once per evaluation, if there's somewhere to keep their results.
+	if stage.cacheSlot > 0 {
+		if cache, ok := parameters.(*cachedParameters); ok {
+			return cache.evaluate(expr, stage)
+		}
+	}
+	return expr.computeStage(stage, parameters, true)
 }
 
+/*
+	Evaluates the given [stage]. Stages with hooks to call, or whose result may be shared, are handed to
+	evaluateObservedStage first, which computes them with [observed] set; every other stage is computed directly,
+	so that those features cost nothing when they aren't used.
+*/
 //nolint: gocognit
-func (expr EvaluableExpression) evaluateStage(stage *evaluationStage, parameters Parameters) (interface{}, error) {
+func (expr *EvaluableExpression) computeStage(stage *evaluationStage, parameters Parameters, observed bool) (interface{}, error) {
 	var left, right interface{}
 	var err error
 
+	if !observed && (expr.Hooks != nil || stage.cacheSlot > 0) {
+		return expr.evaluateObservedStage(stage, parameters)
+	}
+
+	switch stage.symbol {
+
+	// lambdas don't evaluate their body until they're called.
+	case CLOSURE:
+		return expr.makeLambda(stage, parameters), nil
+
+	// let bindings evaluate their value once, then evaluate their body with that value in scope.
+	case BIND:
+		left, err = expr.computeStage(stage.leftStage, parameters, false)
+		if err != nil {
+			return nil, err
+		}
+
+		parameters = scopedParameters{
+			name:   stage.binding,
+			value:  left,
+			parent: parameters,
+		}
+		return expr.computeStage(stage.rightStage, parameters, false)
+	}
+
 	if stage.leftStage != nil {
-		left, err = expr.evaluateStage(stage.leftStage, parameters)
+		left, err = expr.computeStage(stage.leftStage, parameters, false)
 		if err != nil {
 			return nil, err
 		}
@@ -184,7 +221,7 @@ func (expr EvaluableExpression) evaluateStage(stage *evaluationStage, parameters
 				return true, nil
 			}
 		case COALESCE:
-			if left != nil {
+			if !isNil(left) {
 				return left, nil
 			}
 
@@ -193,14 +230,17 @@ func (expr EvaluableExpression) evaluateStage(stage *evaluationStage, parameters
 				right = shortCircuitHolder
 			}
 		case TERNARY_FALSE:
-			if left != nil {
-				right = shortCircuitHolder
+			// the else of `a ? b : c` is only evaluated if `a` was false, even if `b` is null.
+			if left == ternaryUnmatched {
+				left = nil
+			} else if left != nil || findTernaryCondition(stage) != nil {
+				return left, nil
 			}
 		}
 	}
 
 	if right != shortCircuitHolder && stage.rightStage != nil {
-		right, err = expr.evaluateStage(stage.rightStage, parameters)
+		right, err = expr.computeStage(stage.rightStage, parameters, false)
 		if err != nil {
 			return nil, err
 		}
@@ -226,9 +266,21 @@ func (expr EvaluableExpression) evaluateStage(stage *evaluationStage, parameters
 		}
 	}
 
+	if expr.Hooks != nil && stage.symbol == FUNCTIONAL {
+		return expr.hookFunction(stage, left, right, parameters)
+	}
 	return stage.operator(left, right, parameters)
 }
 
+func (expr *EvaluableExpression) makeLambda(stage *evaluationStage, parameters Parameters) ExpressionLambda {
+	return ExpressionLambda{
+		parameter:  stage.binding,
+		body:       stage.rightStage,
+		expression: *expr,
+		parameters: parameters,
+	}
+}
+
 func typeCheck(check stageTypeCheck, value interface{}, symbol OperatorSymbol, format string) error {
 	if check == nil || check(value) {
 		return nil
@@ -252,13 +304,110 @@ func (expr EvaluableExpression) String() string {
 
 /*
 	Returns an array representing the variables contained in this EvaluableExpression.
+	Names which are bound within the expression itself (by a lambda or a let binding) are not included while they are in scope.
 */
 func (expr EvaluableExpression) Vars() []string {
+
 	var varlist []string
-	for _, val := range expr.Tokens() {
-		if val.Kind == VARIABLE {
-			varlist = append(varlist, val.Value.(string))
+	var scope variableScope
+
+	tokens := expr.Tokens()
+
+	for i, val := range tokens {
+
+		switch val.Kind {
+		case CLAUSE:
+			scope.open()
+		case CLAUSE_CLOSE:
+			scope.close()
+		case SEPARATOR:
+			scope.separate()
+		case LET:
+			scope.bind(val.Value.(string), false)
+		case TERMINATOR:
+			scope.terminate()
+		case VARIABLE:
+			name := val.Value.(string)
+
+			if i+1 < len(tokens) && tokens[i+1].Kind == LAMBDA {
+				scope.bind(name, true)
+				continue
+			}
+
+			if !scope.isBound(name) {
+				varlist = append(varlist, name)
+			}
 		}
 	}
 	return varlist
 }
+
+/*
+	Tracks which names are bound while walking the tokens of an expression.
+	A lambda's parameter is in scope until the end of the clause it was defined in, or the next separator in that clause.
+	A let binding is in scope from the end of its value until the end of the clause it was defined in.
+*/
+type variableScope struct {
+	depth    int
+	bindings []scopedBinding
+}
+
+type scopedBinding struct {
+	name      string
+	depth     int
+	lambda    bool
+	activated bool
+}
+
+func (s *variableScope) bind(name string, lambda bool) {
+	s.bindings = append(s.bindings, scopedBinding{
+		name:      name,
+		depth:     s.depth,
+		lambda:    lambda,
+		activated: lambda,
+	})
+}
+
+// activates the innermost let binding, since its value has ended
+func (s *variableScope) terminate() {
+	for i := len(s.bindings) - 1; i >= 0; i-- {
+		if !s.bindings[i].activated {
+			s.bindings[i].activated = true
+			return
+		}
+	}
+}
+
+func (s *variableScope) open() {
+	s.depth++
+}
+
+func (s *variableScope) close() {
+	s.depth--
+	s.release(s.depth+1, false)
+}
+
+func (s *variableScope) separate() {
+	s.release(s.depth, true)
+}
+
+// removes bindings made at the given depth or deeper. If [onlyLambdas] is set, stops at the first binding which isn't a lambda.
+func (s *variableScope) release(depth int, onlyLambdas bool) {
+	for len(s.bindings) > 0 {
+
+		last := s.bindings[len(s.bindings)-1]
+		if last.depth < depth || (onlyLambdas && !last.lambda) {
+			return
+		}
+		s.bindings = s.bindings[:len(s.bindings)-1]
+	}
+}
+
+func (s *variableScope) isBound(name string) bool {
+	for _, binding := range s.bindings {
+		if binding.activated && binding.name == name {
+			return true
+		}
+	}
+	return false
+}
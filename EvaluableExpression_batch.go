@@ -0,0 +1,247 @@
+package govaluate
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+/*
+	A set of parameters for each of many evaluations of the same expression, such as one for each row of a table.
+*/
+type ParameterBatch interface {
+
+	/*
+		The number of sets of parameters in this batch.
+	*/
+	Len() int
+
+	/*
+		Returns the parameters at the given [index], which is at least zero and less than `Len()`.
+		May be called concurrently, when evaluating with more than one worker.
+	*/
+	Row(index int) Parameters
+}
+
+/*
+	A batch made of any kind of Parameters, one for each evaluation.
+*/
+type ParameterSlice []Parameters
+
+func (p ParameterSlice) Len() int {
+	return len(p)
+}
+
+func (p ParameterSlice) Row(index int) Parameters {
+	return p[index]
+}
+
+/*
+	A batch made of maps, one for each evaluation, as would be given to `Evaluate`.
+*/
+type MapParameterSlice []map[string]interface{}
+
+func (p MapParameterSlice) Len() int {
+	return len(p)
+}
+
+func (p MapParameterSlice) Row(index int) Parameters {
+	return MapParameters(p[index])
+}
+
+/*
+	A batch given as columns, where each parameter is a slice which has one value for each evaluation.
+	Row `i` has the value at index `i` of each column. All columns should be of the same length;
+	if they aren't, the batch is as long as the longest, and shorter columns are missing from the rows past their end.
+*/
+type ColumnParameters map[string][]interface{}
+
+func (p ColumnParameters) Len() int {
+
+	var ret int
+
+	for _, column := range p {
+		if len(column) > ret {
+			ret = len(column)
+		}
+	}
+	return ret
+}
+
+func (p ColumnParameters) Row(index int) Parameters {
+	return columnRow{
+		columns: p,
+		index:   index,
+	}
+}
+
+type columnRow struct {
+	columns ColumnParameters
+	index   int
+}
+
+func (r columnRow) Get(name string) (interface{}, error) {
+
+	column, found := r.columns[name]
+
+	if !found || r.index >= len(column) {
+		errorMessage := "No parameter '" + name + "' found."
+		return nil, errors.New(errorMessage)
+	}
+
+	return column[r.index], nil
+}
+
+/*
+	Evaluates this expression once for every row of the given [batch], returning the results in the same order.
+	This is faster than calling `Eval` for each row, since what's needed to evaluate is only set up once.
+
+	If [workers] is more than one, the rows are split between that many goroutines, which evaluate them concurrently.
+	Any functions the expression uses must then be safe to call concurrently.
+	Functions given a lambda must not keep it to be called after they return, since the parameters it was given are reused.
+
+	If any row fails to evaluate, returns the error of the first such row, along with its index.
+*/
+func (expr EvaluableExpression) EvalBatch(batch ParameterBatch, workers int) ([]interface{}, error) {
+
+	ret := make([]interface{}, batch.Len())
+
+	err := expr.evalBatch(batch, workers, func(index int, result interface{}) error {
+		ret[index] = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+/*
+	Evaluates this expression once for every row of the given [batch], returning the indexes of the rows for which it was true, in order.
+	Returns an error if the expression evaluates to anything other than a bool.
+	Otherwise, this behaves the same as `EvalBatch`.
+*/
+func (expr EvaluableExpression) Filter(batch ParameterBatch, workers int) ([]int, error) {
+
+	var ret []int
+
+	matches := make([]bool, batch.Len())
+
+	err := expr.evalBatch(batch, workers, func(index int, result interface{}) error {
+
+		match, ok := result.(bool)
+		if !ok {
+			return fmt.Errorf("Value '%v' cannot be used to filter, it is not a bool", result)
+		}
+
+		matches[index] = match
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, match := range matches {
+		if match {
+			ret = append(ret, i)
+		}
+	}
+	return ret, nil
+}
+
+/*
+	Evaluates this expression for each set of parameters returned by [next], until it returns false,
+	calling [found] with the index and result of each.
+	Meant for rows which are streamed, rather than all available at once. Stops at the first error, either from
+	evaluating or returned by [found].
+*/
+func (expr EvaluableExpression) EvalEach(next func() (Parameters, bool), found func(index int, result interface{}) error) error {
+
+	sanitized := new(sanitizedParameters)
+
+	for index := 0; ; index++ {
+
+		parameters, ok := next()
+		if !ok {
+			return nil
+		}
+
+		result, err := expr.eval(parameters, sanitized)
+		if err != nil {
+			return batchError(index, err)
+		}
+
+		err = found(index, result)
+		if err != nil {
+			return batchError(index, err)
+		}
+	}
+}
+
+/*
+	Evaluates every row of the [batch], split between the given number of [workers], and passes each result to [found].
+	Each worker is given a contiguous range of rows, so that the first error of each range is also the first of all rows.
+*/
+func (expr EvaluableExpression) evalBatch(batch ParameterBatch, workers int, found func(index int, result interface{}) error) error {
+
+	var wait sync.WaitGroup
+
+	length := batch.Len()
+
+	if workers > length {
+		workers = length
+	}
+
+	if workers <= 1 {
+		return expr.evalRows(batch, 0, length, found)
+	}
+
+	errs := make([]error, workers)
+	size := (length + workers - 1) / workers
+
+	for i := 0; i < workers; i++ {
+
+		start := i * size
+		end := start + size
+		if end > length {
+			end = length
+		}
+
+		wait.Add(1)
+		go func(worker int) {
+			defer wait.Done()
+			errs[worker] = expr.evalRows(batch, start, end, found)
+		}(i)
+	}
+	wait.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (expr EvaluableExpression) evalRows(batch ParameterBatch, start, end int, found func(index int, result interface{}) error) error {
+
+	sanitized := new(sanitizedParameters)
+
+	for index := start; index < end; index++ {
+
+		result, err := expr.eval(batch.Row(index), sanitized)
+		if err != nil {
+			return batchError(index, err)
+		}
+
+		err = found(index, result)
+		if err != nil {
+			return batchError(index, err)
+		}
+	}
+	return nil
+}
+
+func batchError(index int, err error) error {
+	return fmt.Errorf("Unable to evaluate row %d: %w", index, err)
+}
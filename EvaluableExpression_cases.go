@@ -0,0 +1,377 @@
+package govaluate
+
+/*
+	A set of parameters generated by `GenerateCases`, along with what the expression evaluated to with them.
+*/
+type GeneratedCase struct {
+	Parameters map[string]interface{} `json:"parameters"`
+
+	/*
+		The result of evaluating the expression with these parameters, or the error it failed with.
+	*/
+	Result interface{} `json:"result"`
+	Error  string      `json:"error,omitempty"`
+
+	/*
+		The branches of the expression which these parameters exercise, and weren't already exercised by an earlier case,
+		such as `x > 5 is false` or `x > 5 with x = 6`.
+	*/
+	Covers []string `json:"covers"`
+}
+
+/*
+	Generates parameters which, between them, exercise every branch of this expression; meant for building tables of
+	regression tests. The branches are
+  - each comparison being both true and false, and for comparisons of a parameter to a number, the parameter
+    being one less than, equal to, and one more than the number,
+  - each side of `&&` and `||` being both true and false, so that each short-circuits and doesn't,
+  - the condition of each ternary being both true and false,
+  - and the left of each `??` being both null and not.
+
+	Parameters are found the same way `CheckSatisfiability` finds them, and only those which have been evaluated to
+	exercise the branch are kept, so branches which are impossible (or which depend on functions, and couldn't be reached)
+	have no case. Lambda bodies aren't covered. Each case covers at least one branch which no earlier case does.
+	Returns an error if the conditions to reach some branch are too large to normalize.
+*/
+func (expr EvaluableExpression) GenerateCases() ([]GeneratedCase, error) {
+
+	var ret []GeneratedCase
+
+	goals, err := findCaseGoals(expr.evaluationStages, [][]NormalTerm{{}}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	problem := newSatisfiabilityProblem(expr)
+	covered := make([]bool, len(goals))
+
+	for i, goal := range goals {
+
+		if covered[i] {
+			continue
+		}
+
+		generated, found := goal.generate(expr, problem)
+		if !found {
+			continue
+		}
+
+		for j, other := range goals {
+			if !covered[j] && other.reachedBy(generated) {
+				covered[j] = true
+				generated.Covers = append(generated.Covers, other.description)
+			}
+		}
+		ret = append(ret, generated.GeneratedCase)
+	}
+	return ret, nil
+}
+
+/*
+	A branch of an expression which a case should exercise; the [stage] being evaluated to [outcome] (if [checksOutcome]),
+	or with the parameter [override] set to [value] (if [overrides]).
+*/
+type caseGoal struct {
+	description string
+	stage       *evaluationStage
+
+	checksOutcome bool
+	outcome       interface{}
+
+	overrides bool
+	override  string
+	value     interface{}
+
+	// for a `??`, whether the [stage] should evaluate to null or not.
+	checksNil bool
+
+	// the clauses of the disjunctive normal form of the conditions on the parameters for this branch to be reached.
+	clauses [][]NormalTerm
+}
+
+/*
+	A generated case, along with the results which each stage of the expression evaluated to.
+*/
+type recordedCase struct {
+	GeneratedCase
+	results map[*evaluationStage][]interface{}
+}
+
+/*
+	Hooks which record the results of every stage which was evaluated.
+*/
+type caseRecorder struct {
+	BaseHooks
+	results map[*evaluationStage][]interface{}
+}
+
+func (recorder *caseRecorder) StageExit(stage HookStage, result interface{}, err error) {
+	if err == nil {
+		recorder.results[stage.stage] = append(recorder.results[stage.stage], result)
+	}
+}
+
+/*
+	Finds the goals within the given [stage], which is reached when the disjunctive [path] is true.
+*/
+func findCaseGoals(stage *evaluationStage, path [][]NormalTerm, found []*caseGoal) ([]*caseGoal, error) {
+
+	var err error
+
+	stage = unwrapNoop(stage)
+	if stage == nil || stage.symbol == CLOSURE {
+		return found, nil
+	}
+
+	leftPath, rightPath := path, path
+
+	switch stage.symbol {
+
+	case EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ, IN:
+		found, err = addOutcomeGoals(stage, path, found)
+		if err != nil {
+			return nil, err
+		}
+		found = addBoundaryGoals(stage, path, found)
+
+	case AND, OR:
+		// the right side is only evaluated if the left doesn't short-circuit.
+		rightPath, err = extendCasePath(path, stage.leftStage, stage.symbol == OR)
+		if err != nil {
+			return nil, err
+		}
+
+		found, err = addOutcomeGoals(stage.leftStage, path, found)
+		if err != nil {
+			return nil, err
+		}
+
+		found, err = addOutcomeGoals(stage.rightStage, rightPath, found)
+		if err != nil {
+			return nil, err
+		}
+
+	case INVERT:
+		found, err = addOutcomeGoals(stage.rightStage, path, found)
+		if err != nil {
+			return nil, err
+		}
+
+	case TERNARY_TRUE:
+		found, err = addOutcomeGoals(stage.leftStage, path, found)
+		if err != nil {
+			return nil, err
+		}
+
+		rightPath, err = extendCasePath(path, stage.leftStage, false)
+		if err != nil {
+			return nil, err
+		}
+
+	case TERNARY_FALSE:
+		condition := unwrapNoop(stage.leftStage)
+		if condition != nil && condition.symbol == TERNARY_TRUE {
+			rightPath, err = extendCasePath(path, condition.leftStage, true)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	case COALESCE:
+		found = addNilGoals(stage, path, found)
+	}
+
+	found, err = findCaseGoals(stage.leftStage, leftPath, found)
+	if err != nil {
+		return nil, err
+	}
+	return findCaseGoals(stage.rightStage, rightPath, found)
+}
+
+/*
+	Returns the given [path], with the condition that the given [stage] is true (or false, if [negated]).
+*/
+func extendCasePath(path [][]NormalTerm, stage *evaluationStage, negated bool) ([][]NormalTerm, error) {
+
+	condition, err := normalizeStage(stage, negated)
+	if err != nil {
+		return nil, err
+	}
+	return multiplyClauses(path, condition)
+}
+
+/*
+	Adds goals for the given [stage] being both true and false, unless they've already been added
+	(as when a comparison is both a comparison and the side of an `&&`).
+*/
+func addOutcomeGoals(stage *evaluationStage, path [][]NormalTerm, found []*caseGoal) ([]*caseGoal, error) {
+
+	stage = unwrapNoop(stage)
+
+	for _, goal := range found {
+		if goal.stage == stage && goal.checksOutcome {
+			return found, nil
+		}
+	}
+
+	for _, outcome := range []bool{true, false} {
+
+		clauses, err := extendCasePath(path, stage, !outcome)
+		if err != nil {
+			return nil, err
+		}
+
+		description := formatStage(stage) + " is false"
+		if outcome {
+			description = formatStage(stage) + " is true"
+		}
+
+		found = append(found, &caseGoal{
+			description:   description,
+			stage:         stage,
+			checksOutcome: true,
+			outcome:       outcome,
+			clauses:       clauses,
+		})
+	}
+	return found, nil
+}
+
+/*
+	Adds goals for the parameter of a comparison to a number being one less than, equal to, and one more than that number.
+*/
+func addBoundaryGoals(stage *evaluationStage, path [][]NormalTerm, found []*caseGoal) []*caseGoal {
+
+	constraint, ok := findConstraint(stage, false)
+	if !ok || constraint.symbol == IN {
+		return found
+	}
+
+	number, ok := constraint.values[0].(float64)
+	if !ok {
+		return found
+	}
+
+	for _, value := range []float64{number - 1, number, number + 1} {
+		found = append(found, &caseGoal{
+			description: formatStage(stage) + " with " + formatName(constraint.name) + " = " + formatLiteral(value),
+			stage:       stage,
+			overrides:   true,
+			override:    constraint.name,
+			value:       value,
+			clauses:     path,
+		})
+	}
+	return found
+}
+
+/*
+	Adds goals for the left side of the given `??` [stage] being both null and not.
+*/
+func addNilGoals(stage *evaluationStage, path [][]NormalTerm, found []*caseGoal) []*caseGoal {
+
+	left := unwrapNoop(stage.leftStage)
+
+	for _, isNil := range []bool{true, false} {
+
+		goal := &caseGoal{
+			description: formatStage(left) + " is not null",
+			stage:       left,
+			checksNil:   true,
+			outcome:     isNil,
+			clauses:     path,
+		}
+
+		if isNil {
+			goal.description = formatStage(left) + " is null"
+		}
+
+		// a parameter can simply be made null.
+		if isNil && left.symbol == VALUE {
+			goal.overrides = true
+			goal.override = left.reference
+		}
+		found = append(found, goal)
+	}
+	return found
+}
+
+/*
+	Finds parameters which reach this goal, trying each clause of its conditions in turn.
+*/
+func (goal *caseGoal) generate(expr EvaluableExpression, problem *satisfiabilityProblem) (recordedCase, bool) {
+
+	for _, clause := range goal.clauses {
+		for attempt := 0; attempt < maxSolveAttempts; attempt++ {
+
+			parameters, possible := problem.solveClause(clause, attempt)
+			if !possible {
+				break
+			}
+
+			if goal.overrides {
+				parameters[goal.override] = goal.value
+			}
+
+			generated := runCase(expr, parameters)
+			if goal.reachedBy(generated) {
+				return generated, true
+			}
+		}
+	}
+	return recordedCase{}, false
+}
+
+/*
+	Whether the given case exercises the branch of this goal.
+*/
+func (goal *caseGoal) reachedBy(generated recordedCase) bool {
+
+	results := generated.results[goal.stage]
+
+	if goal.overrides {
+		value, found := generated.Parameters[goal.override]
+		if !found || value != goal.value {
+			return false
+		}
+	}
+
+	for _, result := range results {
+		switch {
+		case goal.checksOutcome:
+			if result == goal.outcome {
+				return true
+			}
+		case goal.checksNil:
+			if isNil(result) == goal.outcome {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+func runCase(expr EvaluableExpression, parameters map[string]interface{}) recordedCase {
+
+	recorder := &caseRecorder{
+		results: make(map[*evaluationStage][]interface{}),
+	}
+
+	expr.Hooks = chainHooks(expr.Hooks, recorder)
+	result, err := expr.Evaluate(parameters)
+
+	ret := recordedCase{
+		GeneratedCase: GeneratedCase{
+			Parameters: parameters,
+			Result:     result,
+		},
+		results: recorder.results,
+	}
+
+	if err != nil {
+		ret.Error = err.Error()
+	}
+	return ret
+}
@@ -0,0 +1,743 @@
+package govaluate
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/*
+	Describes the Go code generated by `GenerateGo`; which package it's in, and the struct its functions take parameters from.
+*/
+type GoOptions struct {
+
+	/*
+		The package which the generated file is in.
+	*/
+	Package string
+
+	/*
+		The name of the struct type which the generated functions take a pointer to, such as `Input` for `func(p *Input) (bool, error)`.
+	*/
+	Input string
+
+	/*
+		The field of [Input] which holds each parameter, by the name of the parameter.
+	*/
+	Fields map[string]GoField
+
+	/*
+		Whether the [Input] struct is declared in the generated file, with only the [Fields]. Otherwise it's expected to be declared elsewhere.
+	*/
+	DeclareInput bool
+}
+
+/*
+	A field of the struct which generated functions take parameters from.
+*/
+type GoField struct {
+
+	/*
+		The name of the field.
+	*/
+	Name string
+
+	/*
+		The Go type of the field, which must be a bool, a string, or a number (such as `float64` or `int`).
+		Numbers are converted to float64, as they would be if they were given to `Evaluate`.
+	*/
+	Type string
+}
+
+/*
+	A function for `GenerateGo` to generate, which evaluates the given [Expression].
+*/
+type GoFunction struct {
+	Name       string
+	Expression *EvaluableExpression
+}
+
+/*
+	The types which a generated Go value may have. Every value's type is known when it's generated,
+	since the type of every parameter is given.
+*/
+type goType int
+
+const (
+	goNumber goType = iota
+	goString
+	goBool
+	goNull
+	goPattern
+
+	// a value which is never produced, since its evaluation always fails.
+	goFails
+)
+
+type goValue struct {
+	code  string
+	value goType
+}
+
+/*
+	A name bound by a let binding, and whether it's been used.
+*/
+type goBinding struct {
+	goValue
+	used bool
+}
+
+/*
+	Generates the source of a Go file which declares each of the given [functions], like `func(p *Input) (bool, error)`.
+	Each function returns the same result as evaluating its expression with the fields of `p` as parameters would,
+	including errors (with the same messages), short-circuiting, and the way `+` concatenates anything to a string.
+	Regular expressions which are literals are compiled once, when the package is initialized.
+
+	Only expressions which evaluate to a bool can be generated, and only from parameters, literals, operators,
+	and let bindings. Functions, accessors, lambdas, and arrays other than the list of an `in`, aren't supported.
+	Since every value's type must be known, neither are ternaries whose branches may be of different types
+	(including null, such as `a ? b` without an else). Returns an error for anything which isn't supported.
+*/
+func GenerateGo(options GoOptions, functions ...GoFunction) ([]byte, error) {
+
+	var declarations bytes.Buffer
+
+	generator := &goGenerator{
+		options: options,
+		body:    new(bytes.Buffer),
+		imports: make(map[string]bool),
+	}
+
+	for _, function := range functions {
+
+		generator.function = function.Name
+		generator.body.Reset()
+
+		result, err := generator.generate(function.Expression.evaluationStages)
+		if err != nil {
+			return nil, err
+		}
+
+		switch result.value {
+		case goBool:
+			generator.emit("return %s, nil", result.code)
+		case goFails:
+		default:
+			return nil, fmt.Errorf("Cannot generate Go for '%s', since it doesn't evaluate to a bool", function.Expression.String())
+		}
+
+		description := strings.Join(strings.Fields(function.Expression.String()), " ")
+
+		fmt.Fprintf(&declarations, "\n// %s evaluates: %s\n", function.Name, description)
+		fmt.Fprintf(&declarations, "func %s(p *%s) (bool, error) {\n%s}\n", function.Name, options.Input, generator.body.String())
+	}
+
+	var ret bytes.Buffer
+
+	fmt.Fprintf(&ret, "// Code generated by govaluate. DO NOT EDIT.\n\npackage %s\n", options.Package)
+
+	if len(generator.imports) > 0 {
+
+		var imports []string
+		for name := range generator.imports {
+			imports = append(imports, strconv.Quote(name))
+		}
+		sort.Strings(imports)
+
+		fmt.Fprintf(&ret, "\nimport (\n%s\n)\n", strings.Join(imports, "\n"))
+	}
+
+	if len(generator.patterns) > 0 {
+		fmt.Fprintf(&ret, "\nvar (\n%s\n)\n", strings.Join(generator.patterns, "\n"))
+	}
+
+	if options.DeclareInput {
+
+		var names []string
+		for name := range options.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Fprintf(&ret, "\ntype %s struct {\n", options.Input)
+		for _, name := range names {
+			fmt.Fprintf(&ret, "%s %s\n", options.Fields[name].Name, options.Fields[name].Type)
+		}
+		ret.WriteString("}\n")
+	}
+
+	ret.Write(declarations.Bytes())
+	return format.Source(ret.Bytes())
+}
+
+/*
+	Generates the source of a Go file which declares a function of the given [name] that evaluates this expression.
+	See `GenerateGo`.
+*/
+func (expr EvaluableExpression) GenerateGo(name string, options GoOptions) ([]byte, error) {
+	return GenerateGo(options, GoFunction{Name: name, Expression: &expr})
+}
+
+type goGenerator struct {
+	options  GoOptions
+	function string
+
+	body     *bytes.Buffer
+	imports  map[string]bool
+	patterns []string
+	bindings map[string]*goBinding
+	temps    int
+}
+
+func (generator *goGenerator) emit(format string, arguments ...interface{}) {
+	fmt.Fprintf(generator.body, format+"\n", arguments...)
+}
+
+func (generator *goGenerator) temp() string {
+	generator.temps++
+	return "v" + strconv.Itoa(generator.temps)
+}
+
+/*
+	Generates the given [stage] into statements written to the generator's body, which must be run before
+	the returned value's code is evaluated. Statements which fail return an error from the generated function.
+*/
+//nolint: gocognit
+func (generator *goGenerator) generate(stage *evaluationStage) (goValue, error) {
+
+	stage = unwrapNoop(stage)
+	if stage == nil {
+		return goValue{"nil", goNull}, nil
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+		return generator.generateLiteral(stage, value)
+
+	case VALUE:
+		return generator.generateParameter(stage)
+
+	case BIND:
+		return generator.generateBinding(stage)
+
+	case AND, OR:
+		return generator.generateLogical(stage)
+
+	case TERNARY_FALSE, COALESCE:
+		return generator.generateTernary(stage)
+
+	case IN:
+		return generator.generateMembership(stage)
+
+	case INVERT, NEGATE, BITWISE_NOT:
+		right, err := generator.generate(stage.rightStage)
+		if err != nil || right.value == goFails {
+			return right, err
+		}
+
+		switch {
+		case stage.symbol == INVERT && right.value == goBool:
+			return goValue{"!" + right.code, goBool}, nil
+		case stage.symbol == NEGATE && right.value == goNumber:
+			return goValue{"(-" + right.code + ")", goNumber}, nil
+		case stage.symbol == BITWISE_NOT && right.value == goNumber:
+			return goValue{"float64(^int64(" + right.code + "))", goNumber}, nil
+		}
+		return generator.fail(stage, right), nil
+
+	case PLUS, MINUS, MULTIPLY, DIVIDE, MODULUS, EXPONENT,
+		BITWISE_AND, BITWISE_OR, BITWISE_XOR, BITWISE_LSHIFT, BITWISE_RSHIFT,
+		EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ:
+
+		// both sides are evaluated before either is checked.
+		left, err := generator.generate(stage.leftStage)
+		if err != nil || left.value == goFails {
+			return left, err
+		}
+
+		right, err := generator.generate(stage.rightStage)
+		if err != nil || right.value == goFails {
+			return right, err
+		}
+		return generator.generateOperator(stage, left, right)
+	}
+
+	return goValue{}, fmt.Errorf("Cannot generate Go for '%s'", formatStage(stage))
+}
+
+func (generator *goGenerator) generateLiteral(stage *evaluationStage, value interface{}) (goValue, error) {
+
+	switch value := value.(type) {
+
+	case nil:
+		return goValue{"nil", goNull}, nil
+
+	case bool:
+		return goValue{strconv.FormatBool(value), goBool}, nil
+
+	case string:
+		return goValue{strconv.Quote(value), goString}, nil
+
+	case float64:
+		return goValue{generator.formatNumber(value), goNumber}, nil
+
+	case *regexp.Regexp:
+		name := []rune(generator.function + "Pattern" + strconv.Itoa(len(generator.patterns)))
+		name[0] = unicode.ToLower(name[0])
+
+		generator.imports["regexp"] = true
+		generator.patterns = append(generator.patterns, fmt.Sprintf("%s = regexp.MustCompile(%s)", string(name), strconv.Quote(value.String())))
+		return goValue{string(name), goPattern}, nil
+	}
+
+	return goValue{}, fmt.Errorf("Cannot generate Go for '%s', since it's a %T", formatStage(stage), value)
+}
+
+/*
+	Formats a number so that it's a float64 wherever it's used.
+*/
+func (generator *goGenerator) formatNumber(value float64) string {
+
+	switch {
+	case math.IsNaN(value):
+		generator.imports["math"] = true
+		return "math.NaN()"
+	case math.IsInf(value, 0):
+		generator.imports["math"] = true
+		return fmt.Sprintf("math.Inf(%d)", int(math.Copysign(1, value)))
+	}
+
+	ret := strconv.FormatFloat(value, 'g', -1, 64)
+	if !strings.ContainsAny(ret, ".e") {
+		ret += ".0"
+	}
+	if value < 0 || (value == 0 && math.Signbit(value)) {
+		return "(" + ret + ")"
+	}
+	return ret
+}
+
+func (generator *goGenerator) generateParameter(stage *evaluationStage) (goValue, error) {
+
+	if binding, found := generator.bindings[stage.reference]; found {
+		binding.used = true
+		return binding.goValue, nil
+	}
+
+	field, found := generator.options.Fields[stage.reference]
+	if !found {
+		return goValue{}, fmt.Errorf("Cannot generate Go for parameter '%s', since it isn't one of the fields", stage.reference)
+	}
+
+	code := "p." + field.Name
+
+	switch field.Type {
+	case "bool":
+		return goValue{code, goBool}, nil
+	case "string":
+		return goValue{code, goString}, nil
+	case "float64":
+		return goValue{code, goNumber}, nil
+	case "float32", "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return goValue{"float64(" + code + ")", goNumber}, nil
+	}
+
+	return goValue{}, fmt.Errorf("Cannot generate Go for parameter '%s', since its field is a %s, which isn't a bool, string, or number", stage.reference, field.Type)
+}
+
+/*
+	Generates a let binding, whose value is only kept in a variable if the body uses it.
+*/
+func (generator *goGenerator) generateBinding(stage *evaluationStage) (goValue, error) {
+
+	value, err := generator.generate(stage.leftStage)
+	if err != nil || value.value == goFails {
+		return value, err
+	}
+
+	name := generator.temp()
+	binding := &goBinding{goValue: goValue{name, value.value}}
+
+	if value.value == goNull {
+		binding.code = "nil"
+	}
+
+	previous, shadowed := generator.bindings[stage.binding]
+	if generator.bindings == nil {
+		generator.bindings = make(map[string]*goBinding)
+	}
+	generator.bindings[stage.binding] = binding
+
+	body, ret, err := generator.capture(func() (goValue, error) {
+		return generator.generate(stage.rightStage)
+	})
+
+	delete(generator.bindings, stage.binding)
+	if shadowed {
+		generator.bindings[stage.binding] = previous
+	}
+
+	if err != nil {
+		return ret, err
+	}
+
+	if binding.used && value.value != goNull {
+		generator.emit("%s := %s", name, value.code)
+	}
+	generator.body.WriteString(body)
+	return ret, nil
+}
+
+/*
+	Generates an `&&` or `||`, whose right side is only evaluated if the left doesn't short-circuit.
+*/
+func (generator *goGenerator) generateLogical(stage *evaluationStage) (goValue, error) {
+
+	left, err := generator.generate(stage.leftStage)
+	if err != nil || left.value == goFails {
+		return left, err
+	}
+
+	// anything other than a bool never short-circuits, and fails once the right side has been evaluated.
+	if left.value != goBool {
+
+		right, err := generator.generate(stage.rightStage)
+		if err != nil || right.value == goFails {
+			return right, err
+		}
+		return generator.fail(stage, left), nil
+	}
+
+	ret := generator.temp()
+	generator.emit("%s := %s", ret, left.code)
+
+	if stage.symbol == AND {
+		generator.emit("if %s {", ret)
+	} else {
+		generator.emit("if !%s {", ret)
+	}
+
+	right, err := generator.generate(stage.rightStage)
+	if err != nil {
+		return right, err
+	}
+
+	switch right.value {
+	case goBool:
+		generator.emit("%s = %s", ret, right.code)
+	case goFails:
+	default:
+		generator.fail(stage, right)
+	}
+
+	generator.emit("}")
+	return goValue{ret, goBool}, nil
+}
+
+/*
+	Generates a ternary, or a `??`. The left side of either is only replaced by the right if it's null,
+	which a value other than a null literal never is.
+*/
+func (generator *goGenerator) generateTernary(stage *evaluationStage) (goValue, error) {
+
+	condition := unwrapNoop(stage.leftStage)
+	if stage.symbol == COALESCE || condition == nil || condition.symbol != TERNARY_TRUE {
+
+		left, err := generator.generate(stage.leftStage)
+		if err != nil || left.value != goNull {
+			return left, err
+		}
+		return generator.generate(stage.rightStage)
+	}
+
+	test, err := generator.generate(condition.leftStage)
+	if err != nil || test.value == goFails {
+		return test, err
+	}
+
+	// anything other than a bool evaluates the first branch, then fails.
+	if test.value != goBool {
+
+		value, err := generator.generate(condition.rightStage)
+		if err != nil || value.value == goFails {
+			return value, err
+		}
+		return generator.fail(condition, test), nil
+	}
+
+	first, whenTrue, err := generator.capture(func() (goValue, error) {
+		return generator.generate(condition.rightStage)
+	})
+	if err != nil {
+		return whenTrue, err
+	}
+
+	second, whenFalse, err := generator.capture(func() (goValue, error) {
+		return generator.generate(stage.rightStage)
+	})
+	if err != nil {
+		return whenFalse, err
+	}
+
+	value := whenTrue.value
+	if value == goFails {
+		value = whenFalse.value
+	}
+
+	if whenFalse.value != goFails && whenFalse.value != value {
+		return goValue{}, fmt.Errorf("Cannot generate Go for '%s', since its branches may be of different types", formatStage(stage))
+	}
+
+	if value == goFails {
+		generator.emit("if %s {", test.code)
+		generator.body.WriteString(first)
+		generator.emit("} else {")
+		generator.body.WriteString(second)
+		generator.emit("}")
+		return goValue{value: goFails}, nil
+	}
+
+	ret := generator.temp()
+	generator.emit("var %s %s", ret, goTypeNames[value])
+	generator.emit("if %s {", test.code)
+	generator.body.WriteString(first)
+
+	if whenTrue.value != goFails {
+		generator.emit("%s = %s", ret, whenTrue.code)
+	}
+
+	generator.emit("} else {")
+	generator.body.WriteString(second)
+
+	if whenFalse.value != goFails {
+		generator.emit("%s = %s", ret, whenFalse.code)
+	}
+
+	generator.emit("}")
+	return goValue{ret, value}, nil
+}
+
+/*
+	Generates an `in`, which compares its left side to each item of the list on its right, the same way
+	two values in an interface would be; so only items of the same type can be equal.
+*/
+func (generator *goGenerator) generateMembership(stage *evaluationStage) (goValue, error) {
+
+	left, err := generator.generate(stage.leftStage)
+	if err != nil || left.value == goFails {
+		return left, err
+	}
+
+	list := unwrapNoop(stage.rightStage)
+	if list == nil || list.symbol != SEPARATE {
+
+		right, err := generator.generate(stage.rightStage)
+		if err != nil || right.value == goFails {
+			return right, err
+		}
+		return generator.fail(stage, right), nil
+	}
+
+	var comparisons []string
+
+	value := left.code
+	if left.value != goNull {
+		value = generator.temp()
+		generator.emit("%s := %s", value, left.code)
+	}
+
+	for _, item := range findListItems(list, nil) {
+
+		right, err := generator.generate(item)
+		if err != nil || right.value == goFails {
+			return right, err
+		}
+
+		switch {
+		case right.value != left.value:
+		case left.value == goNull:
+			comparisons = append(comparisons, "true")
+		default:
+			comparisons = append(comparisons, value+" == "+right.code)
+		}
+	}
+
+	if len(comparisons) == 0 {
+		if left.value != goNull {
+			generator.emit("_ = %s", value)
+		}
+		return goValue{"false", goBool}, nil
+	}
+	return goValue{"(" + strings.Join(comparisons, " || ") + ")", goBool}, nil
+}
+
+func (generator *goGenerator) generateOperator(stage *evaluationStage, left, right goValue) (goValue, error) {
+
+	numbers := left.value == goNumber && right.value == goNumber
+	texts := left.value == goString && right.value == goString
+	symbol := stage.symbol.String()
+
+	switch stage.symbol {
+
+	case PLUS:
+		switch {
+		case numbers || texts:
+			return goValue{"(" + left.code + " + " + right.code + ")", left.value}, nil
+		case left.value == goNull || right.value == goNull:
+			// null is neither added nor concatenated to anything.
+		case left.value == goString || right.value == goString:
+			generator.imports["fmt"] = true
+			return goValue{"fmt.Sprintf(\"%v%v\", " + left.code + ", " + right.code + ")", goString}, nil
+		}
+		return generator.fail(stage, left), nil
+
+	case GT, LT, GTE, LTE:
+		if numbers || texts {
+			return goValue{"(" + left.code + " " + symbol + " " + right.code + ")", goBool}, nil
+		}
+		return generator.fail(stage, left), nil
+
+	case EQ, NEQ:
+		operator := " == "
+		if stage.symbol == NEQ {
+			operator = " != "
+		}
+
+		switch {
+		case left.value == goPattern || right.value == goPattern:
+			return goValue{}, fmt.Errorf("Cannot generate Go for '%s', since it compares a regular expression", formatStage(stage))
+		case left.value == goNull && right.value == goNull:
+			return goValue{strconv.FormatBool(stage.symbol == EQ), goBool}, nil
+		case left.value != right.value:
+			return goValue{strconv.FormatBool(stage.symbol == NEQ), goBool}, nil
+		}
+		return goValue{"(" + left.code + operator + right.code + ")", goBool}, nil
+
+	case REQ, NREQ:
+		if left.value != goString {
+			return generator.fail(stage, left), nil
+		}
+
+		pattern := right.code
+
+		switch right.value {
+		case goPattern:
+		case goString:
+			pattern = generator.temp()
+			failure := generator.temp()
+
+			generator.imports["fmt"] = true
+			generator.imports["regexp"] = true
+
+			generator.emit("%s, %s := regexp.Compile(%s)", pattern, failure, right.code)
+			generator.emit("if %s != nil {", failure)
+			generator.emit("return false, fmt.Errorf(\"Unable to compile regexp pattern '%%v': %%v\", %s, %s)", right.code, failure)
+			generator.emit("}")
+		default:
+			return generator.fail(stage, right), nil
+		}
+
+		if stage.symbol == NREQ {
+			return goValue{"!" + pattern + ".MatchString(" + left.code + ")", goBool}, nil
+		}
+		return goValue{pattern + ".MatchString(" + left.code + ")", goBool}, nil
+	}
+
+	// the rest are of two numbers, and check the left first.
+	if left.value != goNumber {
+		return generator.fail(stage, left), nil
+	}
+	if right.value != goNumber {
+		return generator.fail(stage, right), nil
+	}
+
+	switch stage.symbol {
+
+	case MODULUS:
+		generator.imports["math"] = true
+		return goValue{"math.Mod(" + left.code + ", " + right.code + ")", goNumber}, nil
+
+	case EXPONENT:
+		generator.imports["math"] = true
+		return goValue{"math.Pow(" + left.code + ", " + right.code + ")", goNumber}, nil
+
+	case DIVIDE:
+		// Go doesn't allow division by a constant zero, but multiplying by the infinity of its sign is the same.
+		if divisor := unwrapNoop(stage.rightStage); isLiteralValue(divisor, 0.0) {
+			value, _ := divisor.operator(nil, nil, nil)
+			infinity := generator.formatNumber(math.Copysign(math.Inf(1), value.(float64)))
+			return goValue{"(" + left.code + " * " + infinity + ")", goNumber}, nil
+		}
+		return goValue{"(" + left.code + " / " + right.code + ")", goNumber}, nil
+
+	case MINUS, MULTIPLY:
+		return goValue{"(" + left.code + " " + symbol + " " + right.code + ")", goNumber}, nil
+
+	case BITWISE_AND, BITWISE_OR, BITWISE_XOR:
+		leftInteger := convertInteger(stage.leftStage, left, "int64")
+		rightInteger := convertInteger(stage.rightStage, right, "int64")
+		return goValue{"float64(" + leftInteger + " " + symbol + " " + rightInteger + ")", goNumber}, nil
+	}
+
+	// shifts.
+	leftInteger := convertInteger(stage.leftStage, left, "uint64")
+	rightInteger := convertInteger(stage.rightStage, right, "uint64")
+	return goValue{"float64(" + leftInteger + " " + symbol + " " + rightInteger + ")", goNumber}, nil
+}
+
+/*
+	Converts a number to the given integer type. Literals are converted as they're generated,
+	since Go doesn't allow constants to be converted to integers they don't fit in, though it allows that of variables.
+*/
+func convertInteger(stage *evaluationStage, value goValue, integer string) string {
+
+	stage = unwrapNoop(stage)
+	if stage.symbol != LITERAL {
+		return integer + "(" + value.code + ")"
+	}
+
+	number, _ := stage.operator(nil, nil, nil)
+	if integer == "int64" {
+		return "int64(" + strconv.FormatInt(int64(number.(float64)), 10) + ")"
+	}
+	return "uint64(" + strconv.FormatUint(uint64(number.(float64)), 10) + ")"
+}
+
+/*
+	Generates the failure of the given [stage]'s type check on the given [value], with the same error the interpreter gives.
+*/
+func (generator *goGenerator) fail(stage *evaluationStage, value goValue) goValue {
+
+	generator.imports["fmt"] = true
+	generator.emit("return false, fmt.Errorf(%s, %s, %s)", strconv.Quote(stage.typeErrorFormat), value.code, strconv.Quote(stage.symbol.String()))
+	return goValue{value: goFails}
+}
+
+/*
+	Runs the given [generate], returning the statements it wrote instead of writing them to the body.
+*/
+func (generator *goGenerator) capture(generate func() (goValue, error)) (string, goValue, error) {
+
+	previous := generator.body
+	generator.body = new(bytes.Buffer)
+
+	ret, err := generate()
+	captured := generator.body.String()
+
+	generator.body = previous
+	return captured, ret, err
+}
+
+var goTypeNames = map[goType]string{
+	goNumber: "float64",
+	goString: "string",
+	goBool:   "bool",
+}
@@ -0,0 +1,150 @@
+package govaluate
+
+import (
+	"sort"
+	"strings"
+)
+
+/*
+	Describes everything an expression needs from outside of itself in order to be evaluated.
+*/
+type Dependencies struct {
+
+	/*
+		The parameters which the expression reads, sorted by name. Each appears only once, no matter how often it's used.
+	*/
+	Parameters []ParameterDependency
+
+	/*
+		The names of the functions which the expression calls (including builtins), sorted.
+		Expressions created by `NewEvaluableExpressionFromTokens` don't know the names of their functions, so they don't report them.
+	*/
+	Functions []string
+}
+
+/*
+	A single parameter which an expression reads.
+*/
+type ParameterDependency struct {
+
+	/*
+		The name of the parameter, which is the first part of any accessors used on it.
+	*/
+	Name string
+
+	/*
+		The full path of every field or method accessed on the parameter (such as "user.Address.City"), sorted.
+		Empty if the parameter is only ever used by itself.
+	*/
+	Accessors []string
+
+	/*
+		True if every use of the parameter is in a branch which may be skipped by short-circuiting;
+		the right side of `&&`, `||`, or `??`, either result of a ternary, or the body of a lambda.
+		Such parameters might not be read at all, depending on the values of the others.
+	*/
+	Conditional bool
+}
+
+/*
+	Returns the dependencies of this expression; the parameters it reads, the fields it accesses on them, and the functions it calls.
+	Unlike `Vars()`, this includes the roots of accessors, doesn't repeat names, and doesn't include the parameters of lambdas or let bindings.
+*/
+func (expr EvaluableExpression) Dependencies() Dependencies {
+
+	var ret Dependencies
+
+	found := make(map[string]*ParameterDependency)
+	expr.findStageDependencies(expr.evaluationStages, nil, false, found)
+
+	for _, parameter := range found {
+		sort.Strings(parameter.Accessors)
+		ret.Parameters = append(ret.Parameters, *parameter)
+	}
+
+	sort.Slice(ret.Parameters, func(i, j int) bool {
+		return ret.Parameters[i].Name < ret.Parameters[j].Name
+	})
+
+	functions := make(map[string]bool)
+	for _, name := range expr.functionNames {
+
+		if !functions[name] {
+			functions[name] = true
+			ret.Functions = append(ret.Functions, name)
+		}
+	}
+
+	sort.Strings(ret.Functions)
+	return ret
+}
+
+/*
+	Records the parameters read by the given [stage] and its children into [found].
+	[bound] are the names of lambda parameters and let bindings which are in scope, and [conditional] is whether
+	this stage is in a branch which may be skipped.
+*/
+func (expr EvaluableExpression) findStageDependencies(stage *evaluationStage, bound []string, conditional bool, found map[string]*ParameterDependency) {
+
+	if stage == nil {
+		return
+	}
+
+	if stage.reference != "" && stage.symbol != FUNCTIONAL {
+		expr.addDependency(stage, bound, conditional, found)
+	}
+
+	expr.findStageDependencies(stage.leftStage, bound, conditional, found)
+
+	switch stage.symbol {
+
+	case CLOSURE:
+		bound = append(bound[:len(bound):len(bound)], stage.binding)
+		conditional = true
+	case BIND:
+		bound = append(bound[:len(bound):len(bound)], stage.binding)
+	default:
+		conditional = conditional || stage.isShortCircuitable()
+	}
+
+	expr.findStageDependencies(stage.rightStage, bound, conditional, found)
+}
+
+func (expr EvaluableExpression) addDependency(stage *evaluationStage, bound []string, conditional bool, found map[string]*ParameterDependency) {
+
+	name := stage.reference
+	path := ""
+
+	if stage.symbol == ACCESS {
+		path = strings.Replace(stage.reference, "?", "", -1)
+		name = strings.SplitN(path, ".", 2)[0]
+	}
+
+	for _, binding := range bound {
+		if binding == name {
+			return
+		}
+	}
+
+	parameter, exists := found[name]
+	if !exists {
+		parameter = &ParameterDependency{
+			Name:        name,
+			Conditional: true,
+		}
+		found[name] = parameter
+	}
+
+	parameter.Conditional = parameter.Conditional && conditional
+
+	if stage.symbol != ACCESS {
+		return
+	}
+
+	for _, accessor := range parameter.Accessors {
+		if accessor == path {
+			return
+		}
+	}
+	parameter.Accessors = append(parameter.Accessors, path)
+}
@@ -0,0 +1,481 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+/*
+	Returns the derivative of the given numeric [expr] with respect to the parameter named [variable];
+	a new expression which evaluates to how fast [expr] changes as [variable] does, with the other parameters held still.
+	Functions of [variable] can't be differentiated, since nothing is known of them; use `Env.Derivative` for that.
+	See `Env.Derivative` for what can be differentiated.
+*/
+func Derivative(expr *EvaluableExpression, variable string) (*EvaluableExpression, error) {
+
+	env := NewEnv()
+	env.ChecksTypes = expr.ChecksTypes
+	env.QueryDateFormat = expr.QueryDateFormat
+	env.Hooks = expr.Hooks
+
+	return env.Derivative(expr, variable)
+}
+
+/*
+	Returns the derivative of the given numeric [expr] with respect to the parameter named [variable], compiled with this Env.
+
+	The operators `+`, `-`, `*`, `/`, and `**` (as long as its exponent doesn't depend on [variable], or its base is
+	a positive number) can be differentiated, as can functions of one argument whose derivative is in this Env's
+	`Derivatives`. The derivative of a ternary is
+	the ternary of the derivatives of its branches, with the same condition, and let bindings are differentiated as if
+	their value were written out wherever they're used. Anything which doesn't depend on [variable] has a derivative of zero.
+	Returns an error if [expr] depends on [variable] through anything else, such as `%` or a bitwise operator.
+
+	The derivative is simplified, such as by leaving out terms which are multiplied by zero, and folding literals together.
+	So unlike [expr], it may not fail for parameters of the wrong type; the derivative of `x * y` with respect to `x` is `y`.
+	Its `String()` is the derivative as it was simplified.
+*/
+func (env *Env) Derivative(expr *EvaluableExpression, variable string) (*EvaluableExpression, error) {
+
+	// the derivative may call any function that [expr] did, as well as those that derivatives call.
+	derived := *env
+	derived.Functions = make(map[string]ExpressionFunction)
+
+	for index, name := range expr.functionNames {
+		if function, ok := expr.tokens[index].Value.(ExpressionFunction); ok {
+			derived.Functions[name] = function
+		}
+	}
+	for name, function := range env.Functions {
+		derived.Functions[name] = function
+	}
+
+	differentiator := differentiator{
+		env:      &derived,
+		variable: variable,
+	}
+
+	stage, err := differentiator.differentiate(expr.evaluationStages)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := derived.Compile(formatStage(stage))
+	if err != nil {
+		return nil, err
+	}
+
+	// compiled again, so that it's written as it was simplified.
+	return derived.Compile(formatStage(ret.evaluationStages))
+}
+
+type differentiator struct {
+	env      *Env
+	variable string
+}
+
+//nolint: gocognit
+func (differentiator differentiator) differentiate(stage *evaluationStage) (*evaluationStage, error) {
+
+	stage = unwrapNoop(stage)
+
+	if !dependsOn(stage, differentiator.variable) {
+		return makeLiteral(0.0), nil
+	}
+
+	var left, right *evaluationStage
+	var err error
+
+	switch stage.symbol {
+
+	case VALUE, ACCESS:
+		if stage.reference == differentiator.variable && stage.rightStage == nil {
+			return makeLiteral(1.0), nil
+		}
+		return nil, fmt.Errorf("Cannot differentiate '%s' with respect to '%s'", formatStage(stage), differentiator.variable)
+
+	case NOOP:
+		return differentiator.differentiate(stage.rightStage)
+
+	case BIND:
+		return differentiator.differentiate(substituteStage(stage.rightStage, stage.binding, stage.leftStage))
+
+	case FUNCTIONAL:
+		return differentiator.differentiateFunction(stage)
+
+	case TERNARY_TRUE:
+		right, err = differentiator.differentiate(stage.rightStage)
+		if err != nil {
+			return nil, err
+		}
+		return makeDerivativeStage(TERNARY_TRUE, stage.leftStage, right), nil
+
+	case TERNARY_FALSE:
+		condition := unwrapNoop(stage.leftStage)
+		if condition.symbol != TERNARY_TRUE {
+			break
+		}
+
+		// the first branch is differentiated on its own, since if it doesn't depend on the variable, the condition still matters.
+		left, err = differentiator.differentiate(condition.rightStage)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err = differentiator.differentiate(stage.rightStage)
+		if err != nil {
+			return nil, err
+		}
+
+		left = makeDerivativeStage(TERNARY_TRUE, condition.leftStage, left)
+		return makeDerivativeStage(TERNARY_FALSE, left, right), nil
+
+	case NEGATE:
+		right, err = differentiator.differentiate(stage.rightStage)
+		if err != nil {
+			return nil, err
+		}
+		return makeNegation(right), nil
+
+	case PLUS, MINUS, MULTIPLY, DIVIDE:
+		left, err = differentiator.differentiate(stage.leftStage)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err = differentiator.differentiate(stage.rightStage)
+		if err != nil {
+			return nil, err
+		}
+
+		switch stage.symbol {
+		case PLUS:
+			return makeSum(left, right), nil
+		case MINUS:
+			return makeDifference(left, right), nil
+		case MULTIPLY:
+			// (uv)' = u'v + uv'
+			return makeSum(makeProduct(left, stage.rightStage), makeProduct(stage.leftStage, right)), nil
+		}
+
+		// (u/v)' = (u'v - uv') / v^2
+		if isLiteralValue(unwrapNoop(right), 0.0) {
+			return makeQuotient(left, stage.rightStage), nil
+		}
+
+		numerator := makeDifference(makeProduct(left, stage.rightStage), makeProduct(stage.leftStage, right))
+		return makeQuotient(numerator, makePower(stage.rightStage, makeLiteral(2.0))), nil
+
+	case EXPONENT:
+		if dependsOn(stage.rightStage, differentiator.variable) {
+			return differentiator.differentiateExponential(stage)
+		}
+
+		left, err = differentiator.differentiate(stage.leftStage)
+		if err != nil {
+			return nil, err
+		}
+
+		// (u^n)' = n * u^(n-1) * u'
+		power := makePower(stage.leftStage, makeDifference(stage.rightStage, makeLiteral(1.0)))
+		return makeProduct(makeProduct(stage.rightStage, power), left), nil
+	}
+
+	return nil, fmt.Errorf("Cannot differentiate '%s', since '%s' isn't differentiable", formatStage(stage), formatSymbol(stage.symbol))
+}
+
+/*
+	Differentiates a power whose exponent depends on the variable; (c^u)' = c^u * ln(c) * u'.
+	Since there's no logarithm to write, its base must be a positive number, whose logarithm is written as a literal.
+*/
+func (differentiator differentiator) differentiateExponential(stage *evaluationStage) (*evaluationStage, error) {
+
+	var value interface{}
+
+	if dependsOn(stage.leftStage, differentiator.variable) {
+		return nil, fmt.Errorf("Cannot differentiate '%s' with respect to '%s', since both its base and exponent depend on it", formatStage(stage), differentiator.variable)
+	}
+
+	base := unwrapNoop(stage.leftStage)
+	if base != nil && base.symbol == LITERAL {
+		value, _ = base.operator(nil, nil, nil)
+	}
+
+	number, isNumber := value.(float64)
+	if !isNumber || number <= 0 {
+		return nil, fmt.Errorf("Cannot differentiate '%s' with respect to '%s', since its exponent depends on it, and its base isn't a positive number", formatStage(stage), differentiator.variable)
+	}
+
+	right, err := differentiator.differentiate(stage.rightStage)
+	if err != nil {
+		return nil, err
+	}
+
+	return makeProduct(makeProduct(stage, makeLiteral(math.Log(number))), right), nil
+}
+
+/*
+	Differentiates a call to a function of one argument by the chain rule; f(u)' = f'(u) * u'.
+*/
+func (differentiator differentiator) differentiateFunction(stage *evaluationStage) (*evaluationStage, error) {
+
+	argument := unwrapNoop(stage.rightStage)
+	if argument == nil || argument.symbol == SEPARATE {
+		return nil, fmt.Errorf("Cannot differentiate '%s', since only functions of one argument can be differentiated", formatStage(stage))
+	}
+
+	derivative, found := differentiator.env.Derivatives[stage.reference]
+	if !found {
+		return nil, fmt.Errorf("No derivative is known for function '%s'", stage.reference)
+	}
+
+	template, err := differentiator.env.Compile(derivative)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to compile the derivative of function '%s': %v", stage.reference, err)
+	}
+
+	inner, err := differentiator.differentiate(argument)
+	if err != nil {
+		return nil, err
+	}
+
+	outer := substituteStage(template.evaluationStages, "x", argument)
+	return makeProduct(outer, inner), nil
+}
+
+/*
+	Whether the given [stage] refers to the parameter named [variable], other than where it's been bound to something else.
+*/
+func dependsOn(stage *evaluationStage, variable string) bool {
+
+	if stage == nil {
+		return false
+	}
+
+	switch stage.symbol {
+
+	case VALUE, ACCESS:
+		if stage.reference == variable || strings.HasPrefix(stage.reference, variable+".") {
+			return true
+		}
+
+	case CLOSURE:
+		if stage.binding == variable {
+			return false
+		}
+
+	case BIND:
+		if stage.binding == variable {
+			return dependsOn(stage.leftStage, variable)
+		}
+	}
+
+	return dependsOn(stage.leftStage, variable) || dependsOn(stage.rightStage, variable)
+}
+
+/*
+	Returns a copy of the given [stage] with every reference to the parameter [name] replaced by the [value] stage.
+*/
+func substituteStage(stage *evaluationStage, name string, value *evaluationStage) *evaluationStage {
+
+	if stage == nil {
+		return nil
+	}
+
+	if stage.symbol == VALUE && stage.reference == name {
+		return groupStage(value, formatStrength(value) < formatStrength(stage))
+	}
+
+	ret := *stage
+	ret.leftStage = substituteStage(stage.leftStage, name, value)
+
+	// a lambda or let binding of the same name hides it from its body.
+	if (stage.symbol != CLOSURE && stage.symbol != BIND) || stage.binding != name {
+		ret.rightStage = substituteStage(stage.rightStage, name, value)
+	}
+	return &ret
+}
+
+func makeSum(left, right *evaluationStage) *evaluationStage {
+
+	switch {
+	case isLiteralValue(unwrapNoop(left), 0.0):
+		return right
+	case isLiteralValue(unwrapNoop(right), 0.0):
+		return left
+	}
+	return makeDerivativeStage(PLUS, left, right)
+}
+
+func makeDifference(left, right *evaluationStage) *evaluationStage {
+
+	switch {
+	case isLiteralValue(unwrapNoop(right), 0.0):
+		return left
+	case isLiteralValue(unwrapNoop(left), 0.0):
+		return makeNegation(right)
+	}
+	return makeDerivativeStage(MINUS, left, right)
+}
+
+func makeProduct(left, right *evaluationStage) *evaluationStage {
+
+	unwrappedLeft := unwrapNoop(left)
+	unwrappedRight := unwrapNoop(right)
+
+	switch {
+	case isLiteralValue(unwrappedLeft, 0.0) || isLiteralValue(unwrappedRight, 0.0):
+		return makeLiteral(0.0)
+	case isLiteralValue(unwrappedLeft, 1.0):
+		return right
+	case isLiteralValue(unwrappedRight, 1.0):
+		return left
+	}
+
+	// literals are kept at the front, so that `2 * (3 * x)` is folded into `6 * x`.
+	if unwrappedRight.symbol == LITERAL {
+		left, right = right, left
+		unwrappedLeft, unwrappedRight = unwrappedRight, unwrappedLeft
+	}
+
+	if unwrappedLeft.symbol == LITERAL && unwrappedRight.symbol == MULTIPLY {
+		inner := unwrapNoop(unwrappedRight.leftStage)
+		if inner.symbol == LITERAL {
+			return makeProduct(makeDerivativeStage(MULTIPLY, unwrappedLeft, inner), unwrappedRight.rightStage)
+		}
+	}
+	return makeDerivativeStage(MULTIPLY, left, right)
+}
+
+func makeQuotient(left, right *evaluationStage) *evaluationStage {
+
+	switch {
+	case isLiteralValue(unwrapNoop(left), 0.0):
+		return makeLiteral(0.0)
+	case isLiteralValue(unwrapNoop(right), 1.0):
+		return left
+	}
+	return makeDerivativeStage(DIVIDE, left, right)
+}
+
+func makePower(left, right *evaluationStage) *evaluationStage {
+
+	switch {
+	case isLiteralValue(unwrapNoop(right), 0.0):
+		return makeLiteral(1.0)
+	case isLiteralValue(unwrapNoop(right), 1.0):
+		return left
+	}
+	return makeDerivativeStage(EXPONENT, left, right)
+}
+
+func makeNegation(right *evaluationStage) *evaluationStage {
+
+	unwrapped := unwrapNoop(right)
+
+	switch unwrapped.symbol {
+	case LITERAL:
+		return foldPrefix(makeStage(NEGATE, nil, unwrapped, prefixErrorFormat))
+	case NEGATE:
+		return unwrapped.rightStage
+	}
+	return makeDerivativeStage(NEGATE, nil, right)
+}
+
+/*
+	Makes a stage of a derivative, folding it if both sides are literals, and otherwise grouping its sides in parenthesis
+	where they'd otherwise be parsed differently.
+*/
+func makeDerivativeStage(symbol OperatorSymbol, left, right *evaluationStage) *evaluationStage {
+
+	unwrappedLeft := unwrapNoop(left)
+	unwrappedRight := unwrapNoop(right)
+
+	// ternaries aren't folded, since their condition has to stay with their else.
+	foldable := symbol != TERNARY_TRUE && symbol != TERNARY_FALSE
+
+	if foldable && unwrappedLeft != nil && unwrappedLeft.symbol == LITERAL && unwrappedRight.symbol == LITERAL {
+		folded := elideStage(makeStage(symbol, unwrappedLeft, unwrappedRight, modifierErrorFormat))
+		if folded.symbol == LITERAL {
+			return folded
+		}
+	}
+
+	strength := formatStrength(&evaluationStage{symbol: symbol})
+
+	switch symbol {
+
+	case NEGATE:
+		right = groupStage(right, formatStrength(right) <= strength)
+
+	case TERNARY_TRUE, TERNARY_FALSE:
+		// the condition of a ternary is written as the left side of its else.
+		if symbol == TERNARY_TRUE || unwrappedLeft.symbol != TERNARY_TRUE {
+			left = groupStage(left, formatStrength(left) == 0)
+		}
+		right = groupStage(right, formatStrength(right) == 0)
+
+	default:
+		// operators are parsed from left to right, except for powers.
+		leftStrength := formatStrength(left)
+		left = groupStage(left, leftStrength < strength || (symbol == EXPONENT && leftStrength == strength))
+		right = groupStage(right, formatStrength(right) <= strength)
+	}
+
+	return makeStage(symbol, left, right, modifierErrorFormat)
+}
+
+/*
+	Returns how tightly the given [stage] is bound when it's written; the higher, the fewer operators it needs parenthesis within.
+	Ternaries and anything which is never an operand of an arithmetic operator are zero.
+*/
+func formatStrength(stage *evaluationStage) int {
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+		if number, ok := value.(float64); ok && number < 0 {
+			return 19
+		}
+		return 20
+	case VALUE, NOOP, FUNCTIONAL, ACCESS:
+		return 20
+	case NEGATE, INVERT, BITWISE_NOT:
+		return 19
+	case EXPONENT:
+		return 18
+	case MULTIPLY, DIVIDE, MODULUS:
+		return 17
+	case PLUS, MINUS:
+		return 16
+	case BITWISE_LSHIFT, BITWISE_RSHIFT:
+		return 15
+	case BITWISE_AND, BITWISE_OR, BITWISE_XOR:
+		return 14
+	case EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ, IN:
+		return 13
+	case AND:
+		return 12
+	case OR:
+		return 11
+	}
+	return 0
+}
+
+/*
+	Wraps the given [stage] in parenthesis, if [grouped] is true.
+*/
+func groupStage(stage *evaluationStage, grouped bool) *evaluationStage {
+
+	if !grouped {
+		return stage
+	}
+
+	return &evaluationStage{
+		symbol:     NOOP,
+		rightStage: stage,
+		operator:   noopStageRight,
+	}
+}
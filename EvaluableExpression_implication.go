@@ -0,0 +1,98 @@
+package govaluate
+
+/*
+	The answer to a question about expressions which can't always be decided, such as `Implies`.
+*/
+type Decision int
+
+const (
+	// it couldn't be decided, because the expressions use something whose meaning isn't known, such as a function.
+	DecisionUnknown Decision = iota
+
+	DecisionFalse
+	DecisionTrue
+)
+
+func (decision Decision) String() string {
+
+	switch decision {
+	case DecisionFalse:
+		return "false"
+	case DecisionTrue:
+		return "true"
+	}
+	return "unknown"
+}
+
+/*
+	Decides whether [a] implies [b]; that is, whether there are no parameters for which [a] is true and [b] is false.
+	For two versions of a rule, this means the rule [a] is at least as narrow as [b]. Like `CheckSatisfiability`,
+	this only considers parameters which the expressions can be evaluated with, and which aren't NaN.
+
+	This can usually be decided for expressions which only compare parameters to literals (see `CheckSatisfiability`),
+	and join those comparisons with `&&`, `||`, and `!`. For expressions which use anything else, such as functions,
+	accessors, or comparisons of two parameters, it's only decided when it doesn't depend on what those evaluate to
+	(such as `f(x) && y` implying `f(x)`), or when parameters are found which show that it's false.
+	Returns an error if the expressions are too large to normalize.
+*/
+func Implies(a, b *EvaluableExpression) (Decision, error) {
+
+	// [a] implies [b] if there are no parameters for which [a] is true and [b] is false.
+	left, err := normalizeStage(a.evaluationStages, false)
+	if err != nil {
+		return DecisionUnknown, err
+	}
+
+	right, err := normalizeStage(b.evaluationStages, true)
+	if err != nil {
+		return DecisionUnknown, err
+	}
+
+	clauses, err := multiplyClauses(left, right)
+	if err != nil {
+		return DecisionUnknown, err
+	}
+
+	counterexample := func(parameters map[string]interface{}) bool {
+
+		result, err := a.Evaluate(parameters)
+		if err != nil || result != true {
+			return false
+		}
+
+		result, err = b.Evaluate(parameters)
+		return err == nil && result == false
+	}
+
+	_, found, decided := newSatisfiabilityProblem(*a, *b).solve(clauses, counterexample)
+
+	switch {
+	case found:
+		return DecisionFalse, nil
+	case decided:
+		return DecisionTrue, nil
+	}
+	return DecisionUnknown, nil
+}
+
+/*
+	Decides whether [a] and [b] are true for exactly the same parameters, by whether each implies the other (see `Implies`).
+	Returns an error if the expressions are too large to normalize.
+*/
+func Equivalent(a, b *EvaluableExpression) (Decision, error) {
+
+	forward, err := Implies(a, b)
+	if err != nil || forward == DecisionFalse {
+		return forward, err
+	}
+
+	backward, err := Implies(b, a)
+	if err != nil || backward == DecisionFalse {
+		return backward, err
+	}
+
+	if forward == DecisionTrue && backward == DecisionTrue {
+		return DecisionTrue, nil
+	}
+	return DecisionUnknown, nil
+}
@@ -0,0 +1,295 @@
+package govaluate
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+	The most clauses a normal form may have. Converting to a normal form can multiply the size of an expression,
+	such as `(a || b) && (c || d) && ...`, so expressions which would need more than this can't be normalized.
+*/
+const maxNormalFormClauses = 4096
+
+/*
+	A boolean expression in conjunctive or disjunctive normal form, as returned by `ToCNF` and `ToDNF`.
+	Anything in the expression which isn't `&&`, `||`, `!`, or parenthesis is a term of the normal form,
+	so `a > 1 && (b || !f(c))` has the terms `a > 1`, `b`, and `f(c)`.
+
+	A normal form is logically equivalent to the expression it came from. Unlike the expression, it doesn't
+	short-circuit in the same order, so it may fail where the expression wouldn't have, such as if a term
+	which was skipped by the expression has a parameter of the wrong type.
+*/
+type NormalForm struct {
+
+	/*
+		True if this is in conjunctive normal form, where each clause is a set of terms joined by `||`,
+		and the clauses are joined by `&&`. Otherwise this is disjunctive, and each is joined by the other.
+	*/
+	Conjunctive bool
+
+	/*
+		The clauses of this form. In disjunctive normal form, no clauses means the expression is always false,
+		and an empty clause means it's always true. In conjunctive normal form, the opposite.
+	*/
+	Clauses [][]NormalTerm
+}
+
+/*
+	A single term of a normal form, which may be negated.
+*/
+type NormalTerm struct {
+
+	/*
+		The term, as it would be written, such as `a > 1`.
+	*/
+	Expression string
+
+	/*
+		True if the term is negated, with a `!`.
+	*/
+	Negated bool
+
+	stage *evaluationStage
+}
+
+/*
+	Returns this expression in conjunctive normal form; an `&&` of clauses, each of which is an `||` of terms.
+	Returns an error if the normal form would be too large.
+*/
+func (expr EvaluableExpression) ToCNF() (NormalForm, error) {
+
+	// the clauses of the conjunctive form are those of the disjunctive form of the negation, with every term negated.
+	clauses, err := normalizeStage(expr.evaluationStages, true)
+	if err != nil {
+		return NormalForm{}, err
+	}
+
+	for _, clause := range clauses {
+		for i := range clause {
+			clause[i].Negated = !clause[i].Negated
+		}
+	}
+
+	return NormalForm{
+		Conjunctive: true,
+		Clauses:     clauses,
+	}, nil
+}
+
+/*
+	Returns this expression in disjunctive normal form; an `||` of clauses, each of which is an `&&` of terms.
+	Returns an error if the normal form would be too large.
+*/
+func (expr EvaluableExpression) ToDNF() (NormalForm, error) {
+
+	clauses, err := normalizeStage(expr.evaluationStages, false)
+	if err != nil {
+		return NormalForm{}, err
+	}
+
+	return NormalForm{
+		Clauses: clauses,
+	}, nil
+}
+
+/*
+	Returns this normal form written as an expression, which has the same result as the expression it came from.
+*/
+func (form NormalForm) String() string {
+
+	inner, outer := " && ", " || "
+	empty, none := "true", "false"
+
+	if form.Conjunctive {
+		inner, outer = outer, inner
+		empty, none = none, empty
+	}
+
+	if len(form.Clauses) == 0 {
+		return none
+	}
+
+	clauses := make([]string, len(form.Clauses))
+
+	for i, clause := range form.Clauses {
+
+		if len(clause) == 0 {
+			clauses[i] = empty
+			continue
+		}
+
+		terms := make([]string, len(clause))
+		for j, term := range clause {
+			terms[j] = term.String()
+		}
+
+		clauses[i] = strings.Join(terms, inner)
+		if len(clause) > 1 && len(form.Clauses) > 1 {
+			clauses[i] = "(" + clauses[i] + ")"
+		}
+	}
+	return strings.Join(clauses, outer)
+}
+
+/*
+	Returns this term written as an expression, with a `!` in front if it's negated.
+*/
+func (term NormalTerm) String() string {
+
+	var simple bool
+
+	switch term.stage.symbol {
+	case VALUE, ACCESS, FUNCTIONAL, LITERAL, NOOP:
+		simple = true
+	}
+
+	switch {
+	case !simple && term.Negated:
+		return "!(" + term.Expression + ")"
+	case term.Negated:
+		return "!" + term.Expression
+	case simple:
+		return term.Expression
+	}
+
+	// terms which contain operators of a lower precedence than `&&` need parenthesis to stay terms.
+	switch term.stage.symbol {
+	case TERNARY_TRUE, TERNARY_FALSE, COALESCE, CLOSURE, BIND, SEPARATE:
+		return "(" + term.Expression + ")"
+	}
+	return term.Expression
+}
+
+/*
+	Returns the clauses of the disjunctive normal form of the given [stage], or of its negation if [negated] is true.
+	Clauses which contain both a term and its negation can never be true, so they're left out.
+*/
+func normalizeStage(stage *evaluationStage, negated bool) ([][]NormalTerm, error) {
+
+	stage = unwrapNoop(stage)
+	if stage == nil {
+		return nil, nil
+	}
+
+	switch stage.symbol {
+
+	case INVERT:
+		return normalizeStage(stage.rightStage, !negated)
+
+	case AND, OR:
+		left, err := normalizeStage(stage.leftStage, negated)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := normalizeStage(stage.rightStage, negated)
+		if err != nil {
+			return nil, err
+		}
+
+		// by De Morgan's laws, a negated `&&` is an `||` of the negations, and the other way around.
+		if (stage.symbol == OR) != negated {
+			return appendClauses(left, right)
+		}
+		return multiplyClauses(left, right)
+
+	case LITERAL:
+		value, err := stage.operator(nil, nil, nil)
+		if err == nil && isBool(value) {
+			if value.(bool) != negated {
+				return [][]NormalTerm{{}}, nil
+			}
+			return nil, nil
+		}
+	}
+
+	term := NormalTerm{
+		Expression: formatStage(stage),
+		Negated:    negated,
+		stage:      stage,
+	}
+	return [][]NormalTerm{{term}}, nil
+}
+
+func appendClauses(left, right [][]NormalTerm) ([][]NormalTerm, error) {
+
+	if len(left)+len(right) > maxNormalFormClauses {
+		return nil, fmt.Errorf("Expression is too large to normalize, it would have more than %d clauses", maxNormalFormClauses)
+	}
+
+	ret := append(left, right...)
+	return removeDuplicateClauses(ret), nil
+}
+
+/*
+	Returns the clauses which are true when one of the [left] clauses and one of the [right] clauses are both true.
+*/
+func multiplyClauses(left, right [][]NormalTerm) ([][]NormalTerm, error) {
+
+	var ret [][]NormalTerm
+
+	if len(left)*len(right) > maxNormalFormClauses {
+		return nil, fmt.Errorf("Expression is too large to normalize, it would have more than %d clauses", maxNormalFormClauses)
+	}
+
+	for _, leftClause := range left {
+		for _, rightClause := range right {
+
+			clause, ok := joinClauses(leftClause, rightClause)
+			if ok {
+				ret = append(ret, clause)
+			}
+		}
+	}
+	return removeDuplicateClauses(ret), nil
+}
+
+/*
+	Returns a clause with the terms of both [left] and [right], each only once.
+	Returns false if one has the negation of a term in the other, since then the clause can never be true.
+*/
+func joinClauses(left, right []NormalTerm) ([]NormalTerm, bool) {
+
+	ret := make([]NormalTerm, len(left), len(left)+len(right))
+	copy(ret, left)
+
+	for _, term := range right {
+
+		duplicate := false
+
+		for _, existing := range left {
+			if existing.Expression != term.Expression {
+				continue
+			}
+			if existing.Negated != term.Negated {
+				return nil, false
+			}
+			duplicate = true
+		}
+
+		if !duplicate {
+			ret = append(ret, term)
+		}
+	}
+	return ret, true
+}
+
+func removeDuplicateClauses(clauses [][]NormalTerm) [][]NormalTerm {
+
+	var ret [][]NormalTerm
+
+	found := make(map[string]bool)
+
+	for _, clause := range clauses {
+
+		key := NormalForm{Clauses: [][]NormalTerm{clause}}.String()
+		if found[key] {
+			continue
+		}
+
+		found[key] = true
+		ret = append(ret, clause)
+	}
+	return ret
+}
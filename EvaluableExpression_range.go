@@ -0,0 +1,555 @@
+package govaluate
+
+import (
+	"math"
+)
+
+/*
+	Returns the range of values this expression may evaluate to, when each parameter is within the given range.
+	Parameters without a range are taken to be anything. For instance, with `qty` given as `IntegerRange(0, math.Inf(1))`
+	and `discount` as `NumberRange(0, 1)`, `qty * 9.99 * (1 - discount)` is in `[0, +Inf]`, which proves it's never negative.
+	A boolean expression's range tells whether it can be true, false, or both.
+
+	The range is found by evaluating each stage over ranges rather than values, so it always holds every value
+	the expression could evaluate to (if it doesn't fail), but may also hold values which it never could;
+	`x - x` isn't known to be 0. The conditions of ternaries, `&&`, and `||` narrow the ranges of the parameters they
+	compare to literals (as `CheckSatisfiability` understands them), so `x > 0 ? x : 0` is known not to be negative.
+	Functions and accessors may return anything. Parameters are assumed never to be NaN, and results which are NaN
+	(such as of `0 / 0`) are taken to be any number.
+*/
+func (expr EvaluableExpression) AnalyzeRange(parameters map[string]Range) Range {
+	return analyzeStage(expr.evaluationStages, parameters)
+}
+
+//nolint: gocognit
+func analyzeStage(stage *evaluationStage, parameters map[string]Range) Range {
+
+	stage = unwrapNoop(stage)
+	if stage == nil {
+		return Range{Nil: true}
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, err := stage.operator(nil, nil, nil)
+		if err != nil {
+			return Range{}
+		}
+		return rangeOf(value)
+
+	case VALUE:
+		ret, found := parameters[stage.reference]
+		if !found {
+			return AnyRange()
+		}
+		return ret
+
+	case NOOP:
+		return analyzeStage(stage.rightStage, parameters)
+
+	case SEPARATE, CLOSURE:
+		return Range{Other: true}
+
+	case BIND:
+		scoped := make(map[string]Range, len(parameters)+1)
+		for name, value := range parameters {
+			scoped[name] = value
+		}
+		scoped[stage.binding] = analyzeStage(stage.leftStage, parameters)
+		return analyzeStage(stage.rightStage, scoped)
+
+	case AND, OR:
+		return analyzeLogical(stage, parameters)
+
+	case TERNARY_TRUE:
+		var ret Range
+
+		condition := analyzeStage(stage.leftStage, parameters)
+		if condition.True {
+			ret = analyzeStage(stage.rightStage, refineRanges(parameters, stage.leftStage, true))
+		}
+		if condition.False {
+			ret.Nil = true
+		}
+		return ret
+
+	case TERNARY_FALSE:
+		return analyzeTernary(stage, parameters)
+
+	case COALESCE:
+		left := analyzeStage(stage.leftStage, parameters)
+		if !left.Nil {
+			return left
+		}
+		return left.withoutNil().Union(analyzeStage(stage.rightStage, parameters))
+
+	case INVERT:
+		right := analyzeStage(stage.rightStage, parameters)
+		return Range{True: right.False, False: right.True}
+
+	case NEGATE:
+		right := analyzeStage(stage.rightStage, parameters)
+		if !right.Number {
+			return Range{}
+		}
+		return makeNumberRange(-right.Max, -right.Min, right.Integer)
+
+	case BITWISE_NOT:
+		right := analyzeStage(stage.rightStage, parameters)
+		if !right.Number {
+			return Range{}
+		}
+
+		// ^x is -x-1 for the whole part of x, as long as it fits.
+		if math.Abs(right.Min) < 1<<53 && math.Abs(right.Max) < 1<<53 {
+			return makeNumberRange(-math.Trunc(right.Max)-1, -math.Trunc(right.Min)-1, true)
+		}
+		return IntegerRange(math.Inf(-1), math.Inf(1))
+
+	case IN:
+		return analyzeMembership(stage, parameters)
+	}
+
+	if stage.leftStage == nil || stage.rightStage == nil {
+		return AnyRange()
+	}
+
+	left := analyzeStage(stage.leftStage, parameters)
+	right := analyzeStage(stage.rightStage, parameters)
+
+	// if either side never evaluates successfully, neither does this.
+	if left.IsEmpty() || right.IsEmpty() {
+		return Range{}
+	}
+
+	switch stage.symbol {
+
+	case EQ, NEQ:
+		var ret Range
+
+		value, single := left.single()
+		otherValue, otherSingle := right.single()
+
+		ret.True = canBeEqual(left, right)
+		ret.False = !single || !otherSingle || !isEqual(value, otherValue)
+
+		if stage.symbol == NEQ {
+			ret.True, ret.False = ret.False, ret.True
+		}
+		return ret
+
+	case GT, LT, GTE, LTE:
+		return analyzeComparison(stage.symbol, left, right)
+
+	case REQ, NREQ:
+		if left.hasStrings() && (right.hasStrings() || right.Other) {
+			return BoolRange()
+		}
+		return Range{}
+
+	case PLUS:
+		ret := Range{}
+		if left.Number && right.Number {
+			ret = makeNumberRange(left.Min+right.Min, left.Max+right.Max, left.Integer && right.Integer)
+		}
+		if left.hasStrings() || right.hasStrings() {
+			ret = ret.Union(concatenateRanges(left, right))
+		}
+		return ret
+	}
+
+	if !left.Number || !right.Number {
+		return Range{}
+	}
+
+	switch stage.symbol {
+
+	case MINUS:
+		return makeNumberRange(left.Min-right.Max, left.Max-right.Min, left.Integer && right.Integer)
+
+	case MULTIPLY:
+		return spanCorners(left, right, multiplyBounds, left.Integer && right.Integer)
+
+	case DIVIDE:
+		if right.Min <= 0 && right.Max >= 0 {
+			return NumberRange(math.Inf(-1), math.Inf(1))
+		}
+		return spanCorners(left, right, func(a, b float64) float64 { return a / b }, false)
+
+	case MODULUS:
+		// a divisor of zero gives NaN, which is taken to be any number.
+		if right.Min <= 0 && right.Max >= 0 {
+			return NumberRange(math.Inf(-1), math.Inf(1))
+		}
+
+		// the remainder has the sign of the dividend, and is smaller than the divisor. Between integers, that's at most
+		// one less than it; otherwise the divisor itself is the (loose) bound, since there's no largest number below it.
+		divisor := math.Max(math.Abs(right.Min), math.Abs(right.Max))
+		if left.Integer && right.Integer {
+			divisor--
+		}
+
+		min, max := 0.0, 0.0
+
+		if left.Min < 0 {
+			min = math.Max(left.Min, -divisor)
+		}
+		if left.Max > 0 {
+			max = math.Min(left.Max, divisor)
+		}
+		return makeNumberRange(min, max, left.Integer && right.Integer)
+
+	case EXPONENT:
+		return analyzeExponent(left, right)
+	}
+
+	// bitwise operators.
+	return IntegerRange(math.Inf(-1), math.Inf(1))
+}
+
+/*
+	Analyzes an `&&` or `||`, whose right side is only evaluated (with the parameters narrowed accordingly)
+	if the left doesn't short-circuit.
+*/
+func analyzeLogical(stage *evaluationStage, parameters map[string]Range) Range {
+
+	shortCircuit := stage.symbol == OR
+
+	left := analyzeStage(stage.leftStage, parameters)
+	ret := Range{True: left.True && shortCircuit, False: left.False && !shortCircuit}
+
+	continues := left.False
+	if stage.symbol == AND {
+		continues = left.True
+	}
+
+	if !continues {
+		return ret
+	}
+
+	right := analyzeStage(stage.rightStage, refineRanges(parameters, stage.leftStage, !shortCircuit))
+	ret.True = ret.True || right.True
+	ret.False = ret.False || right.False
+	return ret
+}
+
+/*
+	Analyzes the else of a ternary. If it's `a ? b : c`, then `c` is evaluated only when `a` is false.
+	Otherwise, it's evaluated when what's on its left is null.
+*/
+func analyzeTernary(stage *evaluationStage, parameters map[string]Range) Range {
+
+	var ret Range
+
+	left := findTernaryCondition(stage)
+	if left == nil {
+
+		value := analyzeStage(stage.leftStage, parameters)
+		if !value.Nil {
+			return value
+		}
+		return value.withoutNil().Union(analyzeStage(stage.rightStage, parameters))
+	}
+
+	condition := analyzeStage(left.leftStage, parameters)
+
+	if condition.True {
+		ret = analyzeStage(left.rightStage, refineRanges(parameters, left.leftStage, true))
+	}
+
+	if condition.False {
+		ret = ret.Union(analyzeStage(stage.rightStage, refineRanges(parameters, left.leftStage, false)))
+	}
+	return ret
+}
+
+/*
+	Analyzes an `in`, which is true if any item of the list on its right is equal to its left.
+*/
+func analyzeMembership(stage *evaluationStage, parameters map[string]Range) Range {
+
+	left := analyzeStage(stage.leftStage, parameters)
+	right := unwrapNoop(stage.rightStage)
+
+	if left.IsEmpty() {
+		return Range{}
+	}
+
+	// the list is only known if it's written out.
+	if right == nil || right.symbol != SEPARATE {
+		if analyzeStage(right, parameters).Other {
+			return BoolRange()
+		}
+		return Range{}
+	}
+
+	var ret Range
+
+	// it can be false if the left can be something which none of the items are known to be.
+	remaining := left
+
+	for _, item := range findListItems(right, nil) {
+
+		itemRange := analyzeStage(item, parameters)
+		if canBeEqual(left, itemRange) {
+			ret.True = true
+		}
+
+		if value, single := itemRange.single(); single {
+			remaining = remaining.exclude(value)
+		}
+	}
+
+	ret.False = !remaining.IsEmpty()
+	return ret
+}
+
+func findListItems(stage *evaluationStage, found []*evaluationStage) []*evaluationStage {
+
+	stage = unwrapNoop(stage)
+	if stage == nil || stage.symbol != SEPARATE {
+		return append(found, stage)
+	}
+
+	found = findListItems(stage.leftStage, found)
+	return findListItems(stage.rightStage, found)
+}
+
+/*
+	Analyzes an ordering comparison, which is either of two numbers, or lexicographic of two strings.
+*/
+func analyzeComparison(symbol OperatorSymbol, left, right Range) Range {
+
+	var ret Range
+
+	// `a < b` is `b > a`.
+	if symbol == LT || symbol == LTE {
+		left, right = right, left
+	}
+	inclusive := symbol == GTE || symbol == LTE
+
+	if left.Number && right.Number {
+		if inclusive {
+			ret.True = left.Max >= right.Min
+			ret.False = left.Min < right.Max
+		} else {
+			ret.True = left.Max > right.Min
+			ret.False = left.Min <= right.Max
+		}
+	}
+
+	if !left.hasStrings() || !right.hasStrings() {
+		return ret
+	}
+
+	if left.AnyString || right.AnyString {
+		return BoolRange()
+	}
+
+	for _, leftValue := range left.Strings {
+		for _, rightValue := range right.Strings {
+
+			result := leftValue > rightValue
+			if inclusive {
+				result = leftValue >= rightValue
+			}
+
+			ret.True = ret.True || result
+			ret.False = ret.False || !result
+		}
+	}
+	return ret
+}
+
+/*
+	Analyzes a power. If the exponent is a known whole number, the base may be anything.
+	Otherwise, the base is expected not to be negative (or the result would be NaN).
+*/
+func analyzeExponent(base, exponent Range) Range {
+
+	integer := base.Integer && exponent.Integer && exponent.Min >= 0
+
+	if value, single := exponent.single(); single && value == math.Trunc(value.(float64)) {
+
+		power := value.(float64)
+		low, high := math.Pow(base.Min, power), math.Pow(base.Max, power)
+
+		switch {
+		case power == 0:
+			return IntegerRange(1, 1)
+		case power < 0 && base.Min <= 0 && base.Max >= 0:
+			return NumberRange(math.Inf(-1), math.Inf(1))
+		case math.Mod(power, 2) == 0 && base.Min < 0 && base.Max > 0:
+			return makeNumberRange(0, math.Max(low, high), integer)
+		}
+		return makeNumberRange(math.Min(low, high), math.Max(low, high), integer)
+	}
+
+	if base.Min < 0 {
+		return NumberRange(math.Inf(-1), math.Inf(1))
+	}
+
+	// b^e is exp(e * ln b), which is greatest and least at the corners, as long as they're finite.
+	if math.IsInf(base.Max, 0) || math.IsInf(exponent.Min, 0) || math.IsInf(exponent.Max, 0) {
+		return makeNumberRange(0, math.Inf(1), integer)
+	}
+	return spanCorners(base, exponent, math.Pow, integer)
+}
+
+/*
+	Returns the range of `left + right` where at least one is a string, and so they're concatenated.
+	Only strings concatenated to strings are kept track of individually.
+*/
+func concatenateRanges(left, right Range) Range {
+
+	onlyStrings := func(r Range) bool {
+		return r.hasStrings() && !r.AnyString && r.withoutStrings().IsEmpty()
+	}
+
+	if !onlyStrings(left) || !onlyStrings(right) || len(left.Strings)*len(right.Strings) > maxRangeStrings {
+		return StringRange()
+	}
+
+	var ret []string
+	for _, leftValue := range left.Strings {
+		for _, rightValue := range right.Strings {
+			ret = append(ret, leftValue+rightValue)
+		}
+	}
+	return StringRange(ret...)
+}
+
+func (r Range) withoutStrings() Range {
+	r.Strings = nil
+	r.AnyString = false
+	return r
+}
+
+/*
+	Returns whether any value of [left] may be equal to any value of [right].
+*/
+func canBeEqual(left, right Range) bool {
+
+	return !left.intersect(right).IsEmpty()
+}
+
+/*
+	Returns the span of [operation] applied to the corners of two ranges of numbers.
+*/
+func spanCorners(left, right Range, operation func(a, b float64) float64, integer bool) Range {
+
+	min, max := math.Inf(1), math.Inf(-1)
+
+	for _, a := range []float64{left.Min, left.Max} {
+		for _, b := range []float64{right.Min, right.Max} {
+
+			value := operation(a, b)
+			if math.IsNaN(value) {
+				return NumberRange(math.Inf(-1), math.Inf(1))
+			}
+
+			min = math.Min(min, value)
+			max = math.Max(max, value)
+		}
+	}
+	return makeNumberRange(min, max, integer)
+}
+
+/*
+	Multiplies two bounds, where zero times infinity is zero, since the infinity stands for arbitrarily large numbers.
+*/
+func multiplyBounds(a, b float64) float64 {
+
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return a * b
+}
+
+/*
+	Returns the given [parameters], narrowed to those for which the given [stage] evaluates to [outcome].
+	Only comparisons of parameters to literals are understood; the parameters are left as they are for anything else.
+*/
+func refineRanges(parameters map[string]Range, stage *evaluationStage, outcome bool) map[string]Range {
+
+	stage = unwrapNoop(stage)
+	if stage == nil {
+		return parameters
+	}
+
+	switch stage.symbol {
+
+	case INVERT:
+		return refineRanges(parameters, stage.rightStage, !outcome)
+
+	case AND, OR:
+		// both sides of a true `&&` are true, and both sides of a false `||` are false.
+		if (stage.symbol == AND) != outcome {
+			return parameters
+		}
+		parameters = refineRanges(parameters, stage.leftStage, outcome)
+		return refineRanges(parameters, stage.rightStage, outcome)
+	}
+
+	constraint, ok := findConstraint(stage, !outcome)
+	if !ok {
+		return parameters
+	}
+
+	current, found := parameters[constraint.name]
+	if !found {
+		current = AnyRange()
+	}
+
+	ret := make(map[string]Range, len(parameters)+1)
+	for name, value := range parameters {
+		ret[name] = value
+	}
+	ret[constraint.name] = constrainRange(current, constraint)
+	return ret
+}
+
+/*
+	Narrows the given range to the values for which the given [constraint] is true.
+*/
+func constrainRange(current Range, constraint parameterConstraint) Range {
+
+	switch constraint.symbol {
+
+	case EQ:
+		return current.intersect(rangeOf(constraint.values[0]))
+
+	case NEQ:
+		return current.exclude(constraint.values[0])
+
+	case IN:
+		if constraint.negated {
+			for _, value := range constraint.values {
+				current = current.exclude(value)
+			}
+			return current
+		}
+
+		var allowed Range
+		for _, value := range constraint.values {
+			allowed = allowed.Union(rangeOf(value))
+		}
+		return current.intersect(allowed)
+	}
+
+	value := constraint.values[0].(float64)
+	bound := NumberRange(value, math.Inf(1))
+
+	if constraint.symbol == LT || constraint.symbol == LTE {
+		bound = NumberRange(math.Inf(-1), value)
+	}
+
+	ret := current.intersect(bound)
+
+	// the ranges are inclusive, so the bound itself can only be left out of whole numbers.
+	if constraint.symbol == GT || constraint.symbol == LT {
+		ret = ret.exclude(value)
+	}
+	return ret
+}
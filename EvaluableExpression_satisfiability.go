@@ -0,0 +1,581 @@
+package govaluate
+
+import (
+	"math"
+	"strconv"
+)
+
+/*
+	Whether an expression can be true, as found by `CheckSatisfiability`.
+*/
+type Satisfiability int
+
+const (
+	// the expression might be true or false, but whether it can be couldn't be determined.
+	SatisfiabilityUnknown Satisfiability = iota
+
+	// the expression is never true, whatever its parameters are.
+	Unsatisfiable
+
+	// the expression is true for some parameters. It's false for others, unless that couldn't be determined.
+	Satisfiable
+
+	// the expression is true for all parameters which it can be evaluated with.
+	Tautology
+)
+
+func (satisfiability Satisfiability) String() string {
+
+	switch satisfiability {
+	case Unsatisfiable:
+		return "unsatisfiable"
+	case Satisfiable:
+		return "satisfiable"
+	case Tautology:
+		return "tautology"
+	}
+	return "unknown"
+}
+
+/*
+	The result of `CheckSatisfiability`.
+*/
+type SatisfiabilityResult struct {
+	Satisfiability Satisfiability
+
+	/*
+		Parameters for which the expression is true, if any were found.
+	*/
+	Assignment map[string]interface{}
+}
+
+/*
+	Finds whether this expression can be true, and if so, parameters for which it is.
+	This is meant for finding rules which are contradictory (such as `x > 5 && x < 3`), or which are always true.
+
+	The terms of the expression (see `ToDNF`) which are understood are comparisons of a parameter to literals;
+	`==`, `!=`, `in`, and the numeric `>`, `>=`, `<`, `<=`, as well as bool parameters on their own. Any other term
+	(such as a function call, or a comparison of two parameters) is treated as being either true or false independently
+	of the others, so an expression which relies on them is only known to be satisfiable if parameters are found
+	which the expression actually evaluates to true with. Otherwise, its satisfiability is unknown.
+
+	Parameters are assumed never to be NaN. An expression which is true for every parameter is only a tautology
+	as long as it evaluates successfully; `x > 1 || x <= 1` fails if `x` is a string.
+	Returns an error if the expression is too large to normalize.
+*/
+func (expr EvaluableExpression) CheckSatisfiability() (SatisfiabilityResult, error) {
+
+	var ret SatisfiabilityResult
+
+	clauses, err := normalizeStage(expr.evaluationStages, false)
+	if err != nil {
+		return ret, err
+	}
+
+	negatedClauses, err := normalizeStage(expr.evaluationStages, true)
+	if err != nil {
+		return ret, err
+	}
+
+	problem := newSatisfiabilityProblem(expr)
+
+	satisfied := func(parameters map[string]interface{}) bool {
+		result, err := expr.Evaluate(parameters)
+		return err == nil && result == true
+	}
+	unsatisfied := func(parameters map[string]interface{}) bool {
+		result, err := expr.Evaluate(parameters)
+		return err == nil && result == false
+	}
+
+	assignment, found, decided := problem.solve(clauses, satisfied)
+	_, foundNegation, decidedNegation := problem.solve(negatedClauses, unsatisfied)
+
+	ret.Assignment = assignment
+
+	switch {
+	case !found && decided:
+		ret.Satisfiability = Unsatisfiable
+	case !foundNegation && decidedNegation:
+		ret.Satisfiability = Tautology
+	case found:
+		ret.Satisfiability = Satisfiable
+	}
+	return ret, nil
+}
+
+/*
+	The most combinations of values which are tried for each clause when solving.
+*/
+const maxSolveAttempts = 8
+
+/*
+	The parameters of one or more expressions, which values are found for.
+*/
+type satisfiabilityProblem struct {
+
+	// the names of every parameter, in the order they're first read, and an example of the type each is compared to (if any).
+	names   []string
+	samples map[string]interface{}
+}
+
+func newSatisfiabilityProblem(expressions ...EvaluableExpression) *satisfiabilityProblem {
+
+	ret := &satisfiabilityProblem{
+		samples: make(map[string]interface{}),
+	}
+
+	for _, expr := range expressions {
+
+		for _, parameter := range expr.Dependencies().Parameters {
+			if _, found := ret.samples[parameter.Name]; !found {
+				ret.names = append(ret.names, parameter.Name)
+				ret.samples[parameter.Name] = nil
+			}
+		}
+		ret.findSamples(expr.evaluationStages)
+	}
+	return ret
+}
+
+/*
+	Finds the type of value that each parameter is compared to, or used as, within the given [stage].
+*/
+func (problem *satisfiabilityProblem) findSamples(stage *evaluationStage) {
+
+	if stage == nil {
+		return
+	}
+
+	switch stage.symbol {
+
+	case AND, OR, INVERT, TERNARY_TRUE:
+		for _, operand := range []*evaluationStage{stage.leftStage, stage.rightStage} {
+
+			operand = unwrapNoop(operand)
+			if operand != nil && operand.symbol == VALUE {
+				problem.addSample(operand.reference, true)
+			}
+
+			// only the condition of a ternary is a bool.
+			if stage.symbol == TERNARY_TRUE {
+				break
+			}
+		}
+
+	case VALUE:
+
+	case EQ, NEQ, GT, LT, GTE, LTE:
+		constraint, ok := findConstraint(stage, false)
+		if ok {
+			problem.addSample(constraint.name, constraint.values[0])
+			break
+		}
+
+		// parameters which may be what's compared to a literal, such as either side of `a ?? b == 'x'`, are compared to it too.
+		left := unwrapNoop(stage.leftStage)
+		right := unwrapNoop(stage.rightStage)
+
+		if left.symbol == LITERAL {
+			left, right = right, left
+		}
+
+		if right.symbol == LITERAL {
+			value, err := right.operator(nil, nil, nil)
+			if err == nil {
+				for _, name := range findOperandParameters(left, nil) {
+					problem.addSample(name, value)
+				}
+			}
+		}
+
+	default:
+		constraint, ok := findConstraint(stage, false)
+		if ok {
+			problem.addSample(constraint.name, constraint.values[0])
+		}
+	}
+
+	problem.findSamples(stage.leftStage)
+	problem.findSamples(stage.rightStage)
+}
+
+/*
+	Returns the names of the parameters which the given [stage] may evaluate to, such as both sides of a `??`.
+*/
+func findOperandParameters(stage *evaluationStage, found []string) []string {
+
+	stage = unwrapNoop(stage)
+	if stage == nil {
+		return found
+	}
+
+	switch stage.symbol {
+	case VALUE:
+		return append(found, stage.reference)
+	case COALESCE, TERNARY_FALSE:
+		found = findOperandParameters(stage.leftStage, found)
+		return findOperandParameters(stage.rightStage, found)
+	case TERNARY_TRUE:
+		return findOperandParameters(stage.rightStage, found)
+	}
+	return found
+}
+
+func (problem *satisfiabilityProblem) addSample(name string, value interface{}) {
+
+	sample, found := problem.samples[name]
+	if found && sample == nil {
+		problem.samples[name] = value
+	}
+}
+
+/*
+	Finds parameters which make one of the given disjunctive [clauses] true, and for which [check] returns true.
+	Returns false if there are none. If [decided] is false, some clauses couldn't be shown to be impossible,
+	but no parameters were found for them either.
+*/
+func (problem *satisfiabilityProblem) solve(clauses [][]NormalTerm, check func(map[string]interface{}) bool) (map[string]interface{}, bool, bool) {
+
+	decided := true
+
+	for _, clause := range clauses {
+
+		// terms which aren't understood may be true for only some of the values allowed by the others, so a few are tried.
+		for attempt := 0; attempt < maxSolveAttempts; attempt++ {
+
+			assignment, possible := problem.solveClause(clause, attempt)
+			if !possible {
+				break
+			}
+
+			if check(assignment) {
+				return assignment, true, true
+			}
+			decided = false
+		}
+	}
+	return nil, false, decided
+}
+
+/*
+	Finds values for every parameter which make each of the understood terms of the [clause] true.
+	Each [attempt] chooses a different combination of values. Returns false only if that's impossible.
+*/
+func (problem *satisfiabilityProblem) solveClause(clause []NormalTerm, attempt int) (map[string]interface{}, bool) {
+
+	domains := make(map[string]*parameterDomain)
+
+	for _, term := range clause {
+
+		constraint, ok := findConstraint(term.stage, term.Negated)
+		if !ok {
+			continue
+		}
+
+		domain := domains[constraint.name]
+		if domain == nil {
+			domain = new(parameterDomain)
+			domains[constraint.name] = domain
+		}
+		domain.add(constraint)
+	}
+
+	ret := make(map[string]interface{})
+
+	for i, name := range problem.names {
+
+		domain := domains[name]
+		if domain == nil {
+			domain = new(parameterDomain)
+		}
+
+		// each attempt chooses the first or second value for each parameter, in a different combination.
+		value, found := domain.find(problem.samples[name], (attempt>>uint(i))&1)
+		if !found {
+			return nil, false
+		}
+		ret[name] = value
+	}
+	return ret, true
+}
+
+/*
+	A term of a normal form which restricts the values of a single parameter, such as `x > 1`.
+	The [symbol] is one of EQ, NEQ, IN (for which [negated] means `not in`), GT, GTE, LT, or LTE.
+*/
+type parameterConstraint struct {
+	name    string
+	symbol  OperatorSymbol
+	negated bool
+	values  []interface{}
+}
+
+/*
+	Returns what the given term restricts its parameter to, if it's a kind of term which is understood.
+*/
+func findConstraint(stage *evaluationStage, negated bool) (parameterConstraint, bool) {
+
+	var ret parameterConstraint
+
+	stage = unwrapNoop(stage)
+
+	// a bool parameter on its own is true if it's true, and its negation if it's false.
+	if stage.symbol == VALUE && stage.reference != "" {
+		ret.name = stage.reference
+		ret.symbol = EQ
+		ret.values = []interface{}{!negated}
+		return ret, true
+	}
+
+	if stage.symbol == NEQ {
+		equality := *stage
+		equality.symbol = EQ
+		stage = &equality
+		negated = !negated
+	}
+
+	predicate, ok := findPredicate(stage)
+	if !ok {
+		return ret, false
+	}
+
+	ret.name = predicate.name
+	ret.symbol = predicate.symbol
+	ret.values = predicate.values
+
+	if !negated {
+		return ret, true
+	}
+
+	switch ret.symbol {
+	case EQ:
+		ret.symbol = NEQ
+	case IN:
+		ret.negated = true
+	case GT:
+		ret.symbol = LTE
+	case GTE:
+		ret.symbol = LT
+	case LT:
+		ret.symbol = GTE
+	case LTE:
+		ret.symbol = GT
+	}
+	return ret, true
+}
+
+/*
+	The values which a parameter may have, given the constraints on it.
+*/
+type parameterDomain struct {
+
+	// if [restricted], the parameter must be one of [allowed].
+	allowed    []interface{}
+	restricted bool
+
+	excluded []interface{}
+
+	// if [numeric], the parameter must be a number within these bounds.
+	numeric      bool
+	lower, upper domainBound
+}
+
+type domainBound struct {
+	value     float64
+	inclusive bool
+	set       bool
+}
+
+func (domain *parameterDomain) add(constraint parameterConstraint) {
+
+	switch constraint.symbol {
+
+	case EQ, IN:
+		if constraint.negated {
+			domain.excluded = append(domain.excluded, constraint.values...)
+			return
+		}
+
+		if !domain.restricted {
+			domain.allowed = constraint.values
+			domain.restricted = true
+			return
+		}
+
+		var allowed []interface{}
+		for _, value := range domain.allowed {
+			if containsValue(constraint.values, value) {
+				allowed = append(allowed, value)
+			}
+		}
+		domain.allowed = allowed
+
+	case NEQ:
+		domain.excluded = append(domain.excluded, constraint.values...)
+
+	case GT, GTE:
+		bound := domainBound{constraint.values[0].(float64), constraint.symbol == GTE, true}
+		if !domain.lower.set || bound.value > domain.lower.value || (bound.value == domain.lower.value && !bound.inclusive) {
+			domain.lower = bound
+		}
+		domain.numeric = true
+
+	case LT, LTE:
+		bound := domainBound{constraint.values[0].(float64), constraint.symbol == LTE, true}
+		if !domain.upper.set || bound.value < domain.upper.value || (bound.value == domain.upper.value && !bound.inclusive) {
+			domain.upper = bound
+		}
+		domain.numeric = true
+	}
+}
+
+/*
+	Returns a value within this domain, preferably of the same type as the [sample], if there is one.
+	If there are more than one, the [choice] is the index of the one to return, otherwise the first is.
+*/
+func (domain *parameterDomain) find(sample interface{}, choice int) (interface{}, bool) {
+
+	var candidates []interface{}
+	var found []interface{}
+
+	// two more candidates than there are exclusions are always enough to find two which aren't excluded, if they can be.
+	count := len(domain.excluded) + 2
+
+	switch {
+	case domain.restricted:
+		candidates = domain.allowed
+	case domain.numeric:
+		candidates = domain.numbers(count)
+	default:
+		candidates = findCandidates(sample, count)
+	}
+
+	for _, candidate := range candidates {
+		if domain.contains(candidate) && !containsValue(found, candidate) {
+			found = append(found, candidate)
+		}
+	}
+
+	switch {
+	case len(found) == 0:
+		return nil, false
+	case choice < len(found):
+		return found[choice], true
+	}
+	return found[0], true
+}
+
+func (domain *parameterDomain) contains(value interface{}) bool {
+
+	if domain.restricted && !containsValue(domain.allowed, value) {
+		return false
+	}
+
+	if containsValue(domain.excluded, value) {
+		return false
+	}
+
+	if !domain.numeric {
+		return true
+	}
+
+	number, ok := value.(float64)
+	if !ok || math.IsNaN(number) {
+		return false
+	}
+
+	if domain.lower.set && (number < domain.lower.value || (number == domain.lower.value && !domain.lower.inclusive)) {
+		return false
+	}
+	if domain.upper.set && (number > domain.upper.value || (number == domain.upper.value && !domain.upper.inclusive)) {
+		return false
+	}
+	return true
+}
+
+/*
+	Returns numbers within the bounds of this domain; whole numbers first, then ones between the bounds.
+*/
+func (domain *parameterDomain) numbers(count int) []interface{} {
+
+	var ret []interface{}
+
+	lower, upper := domain.lower, domain.upper
+
+	if lower.set && lower.inclusive {
+		ret = append(ret, lower.value)
+	}
+	if upper.set && upper.inclusive {
+		ret = append(ret, upper.value)
+	}
+
+	for i := 0; i < count; i++ {
+
+		switch {
+		case lower.set:
+			ret = append(ret, math.Floor(lower.value)+float64(i+1))
+		case upper.set:
+			ret = append(ret, math.Ceil(upper.value)-float64(i+1))
+		default:
+			ret = append(ret, float64(i))
+		}
+	}
+
+	// between two bounds which are close together, there may not be whole numbers.
+	if lower.set && upper.set {
+
+		width := upper.value - lower.value
+		for i := 0; i < count; i++ {
+			width /= 2
+			ret = append(ret, lower.value+width)
+		}
+	}
+	return ret
+}
+
+/*
+	Returns [count] different values of the same type as the given [sample], starting with the sample itself.
+*/
+func findCandidates(sample interface{}, count int) []interface{} {
+
+	var ret []interface{}
+
+	switch sample.(type) {
+
+	case string:
+		ret = append(ret, sample)
+		for i := 0; i < count; i++ {
+			ret = append(ret, "value"+strconv.Itoa(i))
+		}
+
+	// a parameter which is compared to a bool may still be something else, for which `x != true && x != false` is true.
+	case bool:
+		ret = []interface{}{true, false}
+		for i := 0; i < count; i++ {
+			ret = append(ret, "value"+strconv.Itoa(i))
+		}
+
+	case float64:
+		ret = append(ret, sample)
+		for i := 0; i < count; i++ {
+			ret = append(ret, float64(i))
+		}
+
+	default:
+		for i := 0; i < count; i++ {
+			ret = append(ret, float64(i))
+		}
+	}
+	return ret
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,492 @@
+package govaluate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+)
+
+// incremented whenever the serialized form changes in a way that older versions can't read.
+const serializationVersion int = 1
+
+/*
+	Used when deserializing an expression to find the function that was used by the given [name] when the expression was parsed.
+	Returns false if there is no such function.
+	Builtin functions are found automatically, if the resolver doesn't provide a function by the same name.
+*/
+type FunctionResolver func(name string) (ExpressionFunction, bool)
+
+// the symbols that tokens of each kind of operator can have, so that deserialized tokens can't have any others.
+var operatorSymbolsByKind = map[TokenKind]map[string]OperatorSymbol{
+	PREFIX:     prefixSymbols,
+	COMPARATOR: comparatorSymbols,
+	LOGICALOP:  logicalSymbols,
+	MODIFIER:   modifierSymbols,
+	TERNARY:    ternarySymbols,
+	SEPARATOR:  separatorSymbols,
+	LAMBDA:     lambdaSymbols,
+}
+
+/*
+	The form in which expressions are serialized.
+	Only the tokens are kept, since those are what's expensive to produce (especially dates, which are tried for every string).
+	Evaluation stages are re-planned from them when deserialized.
+*/
+type serializedExpression struct {
+	Version         int               `json:"version"`
+	Expression      string            `json:"expression"`
+	QueryDateFormat string            `json:"queryDateFormat"`
+	ChecksTypes     bool              `json:"checksTypes"`
+	Tokens          []serializedToken `json:"tokens"`
+}
+
+/*
+	A single token, with its value in whichever field matches the type of value it has.
+	Strings, patterns, times, and the names of functions and variables are all kept as text.
+*/
+type serializedToken struct {
+	Kind   string   `json:"kind"`
+	Text   string   `json:"text,omitempty"`
+	Number float64  `json:"number,omitempty"`
+	Bool   bool     `json:"bool,omitempty"`
+	Parts  []string `json:"parts,omitempty"`
+}
+
+/*
+	Serializes this expression into a compact binary form, which can be loaded much more quickly than the expression can be parsed.
+	Functions are serialized by the name they were called by in the expression, so expressions created by
+	`NewEvaluableExpressionFromTokens` which use functions cannot be serialized.
+*/
+func (expr EvaluableExpression) MarshalBinary() ([]byte, error) {
+
+	serialized, err := expr.serialize()
+	if err != nil {
+		return nil, err
+	}
+	return serialized.encode(), nil
+}
+
+/*
+	Deserializes an expression produced by `MarshalBinary`. Only builtin functions can be used;
+	to use any other functions, use `UnmarshalEvaluableExpression` instead.
+*/
+func (expr *EvaluableExpression) UnmarshalBinary(data []byte) error {
+
+	loaded, err := UnmarshalEvaluableExpression(data, nil)
+	if err != nil {
+		return err
+	}
+
+	*expr = *loaded
+	return nil
+}
+
+/*
+	Same as `MarshalBinary`, but produces JSON, which is larger and slower but can be read (or produced) by other tools.
+*/
+func (expr EvaluableExpression) MarshalJSON() ([]byte, error) {
+
+	serialized, err := expr.serialize()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(serialized)
+}
+
+/*
+	Deserializes an expression produced by `MarshalJSON`. Only builtin functions can be used;
+	to use any other functions, use `UnmarshalEvaluableExpressionJSON` instead.
+*/
+func (expr *EvaluableExpression) UnmarshalJSON(data []byte) error {
+
+	loaded, err := UnmarshalEvaluableExpressionJSON(data, nil)
+	if err != nil {
+		return err
+	}
+
+	*expr = *loaded
+	return nil
+}
+
+/*
+	Deserializes an expression produced by `MarshalBinary`, using the given [resolver] to find the functions it uses.
+	The [resolver] may be nil, if the expression uses no functions (or only builtins).
+*/
+func UnmarshalEvaluableExpression(data []byte, resolver FunctionResolver) (*EvaluableExpression, error) {
+
+	serialized, err := decodeSerializedExpression(data)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to deserialize expression: %v", err)
+	}
+	return serialized.deserialize(resolver)
+}
+
+/*
+	Deserializes an expression produced by `MarshalJSON`, using the given [resolver] to find the functions it uses.
+	The [resolver] may be nil, if the expression uses no functions (or only builtins).
+*/
+func UnmarshalEvaluableExpressionJSON(data []byte, resolver FunctionResolver) (*EvaluableExpression, error) {
+
+	var serialized serializedExpression
+
+	err := json.Unmarshal(data, &serialized)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to deserialize expression: %v", err)
+	}
+	return serialized.deserialize(resolver)
+}
+
+func (expr EvaluableExpression) serialize() (serializedExpression, error) {
+
+	ret := serializedExpression{
+		Version:         serializationVersion,
+		Expression:      expr.inputExpression,
+		QueryDateFormat: expr.QueryDateFormat,
+		ChecksTypes:     expr.ChecksTypes,
+		Tokens:          make([]serializedToken, len(expr.tokens)),
+	}
+
+	for i, token := range expr.tokens {
+
+		serialized := serializedToken{
+			Kind: token.Kind.String(),
+		}
+
+		if token.Kind == FUNCTION {
+
+			name, found := expr.functionNames[i]
+			if !found {
+				return ret, fmt.Errorf("Unable to serialize function at token %d, its name is unknown", i)
+			}
+
+			serialized.Text = name
+			ret.Tokens[i] = serialized
+			continue
+		}
+
+		switch value := token.Value.(type) {
+
+		case float64:
+			serialized.Number = value
+		case bool:
+			serialized.Bool = value
+		case string:
+			serialized.Text = value
+		case []string:
+			serialized.Parts = value
+		case *regexp.Regexp:
+			serialized.Text = value.String()
+		case time.Time:
+			serialized.Text = value.Format(time.RFC3339Nano)
+		}
+
+		ret.Tokens[i] = serialized
+	}
+	return ret, nil
+}
+
+func (serialized serializedExpression) deserialize(resolver FunctionResolver) (*EvaluableExpression, error) {
+
+	var function ExpressionFunction
+	var kind TokenKind
+	var err error
+	var found bool
+
+	if serialized.Version != serializationVersion {
+		return nil, fmt.Errorf("Unable to deserialize expression of version %d, only version %d is supported", serialized.Version, serializationVersion)
+	}
+
+	env := NewEnv()
+	tokens := make([]ExpressionToken, len(serialized.Tokens))
+	functionNames := make(map[int]string)
+
+	for i, token := range serialized.Tokens {
+
+		kind, err = findTokenKind(token.Kind)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens[i].Kind = kind
+
+		switch kind {
+
+		case NUMERIC:
+			tokens[i].Value = token.Number
+		case BOOLEAN:
+			tokens[i].Value = token.Bool
+		case ACCESSOR:
+			if len(token.Parts) == 0 {
+				return nil, fmt.Errorf("Unable to deserialize ACCESSOR token %d, it has no parts", i)
+			}
+			tokens[i].Value = token.Parts
+		case NULL:
+			tokens[i].Value = nil
+		case CLAUSE:
+			tokens[i].Value = '('
+		case CLAUSE_CLOSE:
+			tokens[i].Value = ')'
+
+		case PATTERN:
+			tokens[i].Value, err = regexp.Compile(token.Text)
+			if err != nil {
+				return nil, err
+			}
+
+		case TIME:
+			tokens[i].Value, err = time.Parse(time.RFC3339Nano, token.Text)
+			if err != nil {
+				return nil, err
+			}
+
+		case FUNCTION:
+			found = false
+			if resolver != nil {
+				function, found = resolver(token.Text)
+			}
+
+			// functions which are resolved aren't known to be pure, so calls to them aren't shared.
+			if found {
+				env.Functions[token.Text] = function
+			}
+
+			if !found {
+				function, found = builtinFunctions[token.Text]
+			}
+			if !found {
+				return nil, errors.New("Undefined function " + token.Text)
+			}
+
+			tokens[i].Value = function
+			functionNames[i] = token.Text
+
+		default:
+			symbols, isOperator := operatorSymbolsByKind[kind]
+			if isOperator {
+				if _, found = symbols[token.Text]; !found {
+					return nil, fmt.Errorf("Unable to deserialize %s token %d, '%s' is not a valid symbol", kind, i, token.Text)
+				}
+			}
+			tokens[i].Value = token.Text
+		}
+	}
+
+	ret, err := env.compileTokens(tokens, functionNames)
+	if err != nil {
+		return nil, err
+	}
+
+	ret.inputExpression = serialized.Expression
+	ret.QueryDateFormat = serialized.QueryDateFormat
+	ret.ChecksTypes = serialized.ChecksTypes
+	return ret, nil
+}
+
+/*
+	Finds the TokenKind whose String() is the given [name].
+*/
+func findTokenKind(name string) (TokenKind, error) {
+
+	for _, state := range validLexerStates {
+		if state.kind.String() == name {
+			return state.kind, nil
+		}
+	}
+	return UNKNOWN, fmt.Errorf("Unable to deserialize token of unknown kind '%s'", name)
+}
+
+/*
+	Encodes the expression in the binary form. Every token is written with all of its fields,
+	strings are prefixed by their length, and numbers are written as varints.
+*/
+func (serialized serializedExpression) encode() []byte {
+
+	var buffer bytes.Buffer
+
+	writeUvarint(&buffer, uint64(serialized.Version))
+	writeString(&buffer, serialized.Expression)
+	writeString(&buffer, serialized.QueryDateFormat)
+	writeBool(&buffer, serialized.ChecksTypes)
+	writeUvarint(&buffer, uint64(len(serialized.Tokens)))
+
+	for _, token := range serialized.Tokens {
+
+		writeString(&buffer, token.Kind)
+		writeString(&buffer, token.Text)
+		writeUvarint(&buffer, math.Float64bits(token.Number))
+		writeBool(&buffer, token.Bool)
+		writeUvarint(&buffer, uint64(len(token.Parts)))
+
+		for _, part := range token.Parts {
+			writeString(&buffer, part)
+		}
+	}
+	return buffer.Bytes()
+}
+
+func decodeSerializedExpression(data []byte) (serializedExpression, error) {
+
+	var ret serializedExpression
+	var length uint64
+
+	reader := bytes.NewReader(data)
+
+	version, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return ret, err
+	}
+
+	// checked here as well as when deserializing, since other versions may not even be decodable.
+	ret.Version = int(version)
+	if ret.Version != serializationVersion {
+		return ret, nil
+	}
+
+	ret.Expression, err = readString(reader)
+	if err != nil {
+		return ret, err
+	}
+
+	ret.QueryDateFormat, err = readString(reader)
+	if err != nil {
+		return ret, err
+	}
+
+	ret.ChecksTypes, err = readBool(reader)
+	if err != nil {
+		return ret, err
+	}
+
+	length, err = readLength(reader)
+	if err != nil {
+		return ret, err
+	}
+
+	ret.Tokens = make([]serializedToken, length)
+	for i := range ret.Tokens {
+
+		ret.Tokens[i], err = readSerializedToken(reader)
+		if err != nil {
+			return ret, err
+		}
+	}
+
+	if reader.Len() > 0 {
+		return ret, errors.New("unexpected data after the last token")
+	}
+	return ret, nil
+}
+
+func readSerializedToken(reader *bytes.Reader) (serializedToken, error) {
+
+	var ret serializedToken
+	var number, length uint64
+	var err error
+
+	ret.Kind, err = readString(reader)
+	if err != nil {
+		return ret, err
+	}
+
+	ret.Text, err = readString(reader)
+	if err != nil {
+		return ret, err
+	}
+
+	number, err = binary.ReadUvarint(reader)
+	if err != nil {
+		return ret, err
+	}
+	ret.Number = math.Float64frombits(number)
+
+	ret.Bool, err = readBool(reader)
+	if err != nil {
+		return ret, err
+	}
+
+	length, err = readLength(reader)
+	if err != nil {
+		return ret, err
+	}
+
+	if length > 0 {
+		ret.Parts = make([]string, length)
+	}
+
+	for i := range ret.Parts {
+
+		ret.Parts[i], err = readString(reader)
+		if err != nil {
+			return ret, err
+		}
+	}
+	return ret, nil
+}
+
+func writeUvarint(buffer *bytes.Buffer, value uint64) {
+
+	var encoded [binary.MaxVarintLen64]byte
+
+	length := binary.PutUvarint(encoded[:], value)
+	buffer.Write(encoded[:length])
+}
+
+func writeString(buffer *bytes.Buffer, value string) {
+	writeUvarint(buffer, uint64(len(value)))
+	buffer.WriteString(value)
+}
+
+func writeBool(buffer *bytes.Buffer, value bool) {
+	if value {
+		buffer.WriteByte(1)
+		return
+	}
+	buffer.WriteByte(0)
+}
+
+/*
+	Reads the length of a string or slice, making sure that there's at least that much data left to read,
+	so that a corrupt length can't cause a huge allocation.
+*/
+func readLength(reader *bytes.Reader) (uint64, error) {
+
+	length, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return 0, err
+	}
+
+	if length > uint64(reader.Len()) {
+		return 0, errors.New("length exceeds the remaining data")
+	}
+	return length, nil
+}
+
+func readString(reader *bytes.Reader) (string, error) {
+
+	length, err := readLength(reader)
+	if err != nil {
+		return "", err
+	}
+
+	ret := make([]byte, length)
+
+	_, err = reader.Read(ret)
+	if err != nil && length > 0 {
+		return "", err
+	}
+	return string(ret), nil
+}
+
+func readBool(reader *bytes.Reader) (bool, error) {
+
+	value, err := reader.ReadByte()
+	if err != nil {
+		return false, err
+	}
+	return value != 0, nil
+}
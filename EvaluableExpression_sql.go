@@ -71,6 +71,9 @@ func (expr EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 			ret = "0"
 		}
 
+	case NULL:
+		ret = "NULL"
+
 	case VARIABLE:
 		ret = fmt.Sprintf("[%s]", token.Value.(string))
 
@@ -82,8 +85,18 @@ func (expr EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 
 		case EQ:
 			ret = "="
+			if isFollowedByNull(stream) {
+				ret = "IS"
+			} else if isPrecededByNull(transactions) {
+				return expr.findNullComparisonSQL(stream, transactions, "IS NULL")
+			}
 		case NEQ:
 			ret = "<>"
+			if isFollowedByNull(stream) {
+				ret = "IS NOT"
+			} else if isPrecededByNull(transactions) {
+				return expr.findNullComparisonSQL(stream, transactions, "IS NOT NULL")
+			}
 		case REQ:
 			ret = "RLIKE"
 		case NREQ:
@@ -161,3 +174,69 @@ func (expr EvaluableExpression) findNextSQLString(stream *tokenStream, transacti
 
 	return ret, nil
 }
+
+/*
+	SQL never considers anything to be `= NULL`, so comparisons against null need to be written as `IS NULL` instead.
+*/
+func isFollowedByNull(stream *tokenStream) bool {
+	return stream.hasNext() && stream.tokens[stream.index].Kind == NULL
+}
+
+/*
+	Returns true if the last thing written was null, as in `null == foo`.
+*/
+func isPrecededByNull(transactions *expressionOutputStream) bool {
+
+	count := len(transactions.transactions)
+	return count > 0 && transactions.transactions[count-1] == "NULL"
+}
+
+/*
+	Writes a comparison with null on its left, like `null == foo`, as `[foo] IS NULL`, since SQL only allows null
+	on the right of `IS`. The null is rolled back, and the other side is everything up to the next operator
+	which doesn't bind more tightly than the comparison.
+*/
+func (expr EvaluableExpression) findNullComparisonSQL(stream *tokenStream, transactions *expressionOutputStream, comparison string) (string, error) {
+
+	transactions.rollback()
+
+	operand := new(expressionOutputStream)
+	depth := 0
+	expectsValue := true
+
+	for stream.hasNext() {
+
+		token := stream.tokens[stream.index]
+		if depth == 0 && !expectsValue && token.Kind != MODIFIER {
+			break
+		}
+
+		transaction, err := expr.findNextSQLString(stream, operand)
+		if err != nil {
+			return "", err
+		}
+		operand.add(transaction)
+
+		// prefixes other than `!`, and modifiers written as functions, write their right side along with themselves.
+		switch token.Kind {
+		case CLAUSE:
+			depth++
+			expectsValue = true
+		case CLAUSE_CLOSE:
+			depth--
+			expectsValue = false
+		case PREFIX:
+			expectsValue = prefixSymbols[token.Value.(string)] == INVERT
+		case MODIFIER:
+			symbol := modifierSymbols[token.Value.(string)]
+			expectsValue = symbol != EXPONENT && symbol != MODULUS
+		default:
+			expectsValue = false
+		}
+	}
+
+	if len(operand.transactions) == 0 {
+		return "", errors.New("Unable to compare null to nothing")
+	}
+	return fmt.Sprintf("%s %s", operand.createString(" "), comparison), nil
+}
@@ -0,0 +1,285 @@
+package govaluate
+
+import (
+	"strings"
+)
+
+/*
+	A record of how one part of an expression was evaluated, as produced by `EvalWithTrace`.
+	Together, the nodes of a trace form a tree which mirrors the expression; each operator has its operands as children.
+*/
+type TraceNode struct {
+
+	/*
+		The part of the expression this node evaluated, such as `age > 18`.
+	*/
+	Expression string `json:"expression"`
+
+	/*
+		The operator of this part of the expression, or empty if it's a single value such as a parameter.
+	*/
+	Operator string `json:"operator,omitempty"`
+
+	/*
+		The values of the left and right sides of the operator, if it had them and they were evaluated.
+		For a function, the right side is its arguments.
+	*/
+	Left  interface{} `json:"left,omitempty"`
+	Right interface{} `json:"right,omitempty"`
+
+	/*
+		The value this part of the expression evaluated to.
+	*/
+	Result interface{} `json:"result"`
+
+	/*
+		The error this part of the expression failed with, if any.
+	*/
+	Error string `json:"error,omitempty"`
+
+	/*
+		True if this part of the expression was skipped, because the result was already known from the part before it;
+		such as the right side of an `&&` whose left side is false. Skipped parts have no result or children.
+	*/
+	ShortCircuited bool `json:"shortCircuited,omitempty"`
+
+	Children []*TraceNode `json:"children,omitempty"`
+
+	stage *evaluationStage
+}
+
+/*
+	Hooks which record every stage that's evaluated into a trace.
+*/
+type expressionTracer struct {
+	BaseHooks
+
+	// the node which stages being evaluated are children of, and the nodes which it's a child of.
+	current *TraceNode
+	parents []*TraceNode
+}
+
+/*
+	Same as `Eval`, but also returns a trace of every part of the expression that was evaluated, with the values each part
+	evaluated to, and which parts were skipped. The trace can be rendered as an indented tree with `String()`, or marshaled to JSON.
+	This is much slower than `Eval`, and meant for finding out why an expression gave the result it did.
+
+	Returns a trace even if evaluation fails, showing what failed. The trace is nil only if the expression is empty.
+*/
+func (expr EvaluableExpression) EvalWithTrace(parameters Parameters) (interface{}, *TraceNode, error) {
+
+	root := new(TraceNode)
+
+	expr.Hooks = chainHooks(expr.Hooks, &expressionTracer{
+		current: root,
+	})
+
+	result, err := expr.Eval(parameters)
+
+	if len(root.Children) == 0 {
+		return result, nil, err
+	}
+	return result, root.Children[0], err
+}
+
+/*
+	Records the given [stage] as a child of the current node, which its own children are then recorded into.
+*/
+func (tracer *expressionTracer) StageEnter(stage HookStage) {
+
+	node := newTraceNode(stage.stage)
+
+	tracer.current.Children = append(tracer.current.Children, node)
+	tracer.parents = append(tracer.parents, tracer.current)
+	tracer.current = node
+}
+
+func (tracer *expressionTracer) StageExit(stage HookStage, result interface{}, err error) {
+
+	tracer.current.finish(result, err)
+
+	tracer.current = tracer.parents[len(tracer.parents)-1]
+	tracer.parents = tracer.parents[:len(tracer.parents)-1]
+}
+
+func newTraceNode(stage *evaluationStage) *TraceNode {
+	return &TraceNode{
+		Expression: formatStage(stage),
+		Operator:   HookStage{stage}.Operator(),
+		stage:      stage,
+	}
+}
+
+/*
+	Records the [result] of this node's stage, and the values of its operands, which are the results of its children.
+*/
+func (node *TraceNode) finish(result interface{}, err error) {
+
+	var evaluatedRight bool
+
+	node.Result = traceValue(result)
+	if err != nil {
+		node.Error = err.Error()
+	}
+
+	left := unwrapNoop(node.stage.leftStage)
+	right := unwrapNoop(node.stage.rightStage)
+
+	for _, child := range node.Children {
+
+		if child.stage == left {
+			node.Left = child.Result
+		}
+
+		if child.stage == right {
+			node.Right = child.Result
+			evaluatedRight = true
+		}
+	}
+
+	if right != nil && !evaluatedRight && err == nil && node.stage.isShortCircuitable() {
+
+		skipped := newTraceNode(right)
+		skipped.ShortCircuited = true
+
+		node.Children = append(node.Children, skipped)
+	}
+}
+
+// a lambda in a trace, as it was written.
+type tracedLambda string
+
+/*
+	Returns the given [value] as it's kept in a trace. Lambdas are kept as they were written (including those in arrays),
+	everything else is unchanged.
+*/
+func traceValue(value interface{}) interface{} {
+
+	switch value := value.(type) {
+
+	case ExpressionLambda:
+		return tracedLambda(formatStage(&evaluationStage{
+			symbol:     CLOSURE,
+			binding:    value.parameter,
+			rightStage: value.body,
+		}))
+
+	case []interface{}:
+		ret := make([]interface{}, len(value))
+		for i, element := range value {
+			ret[i] = traceValue(element)
+		}
+		return ret
+	}
+	return value
+}
+
+/*
+	Renders this node and its children as an indented tree, one line per node, such as
+
+	age > 18 && plan == 'pro' → false
+
+	  age > 18 → false because age = 17
+	  plan == 'pro' (short-circuited)
+
+	Literals aren't shown, and the values of parameters are shown on the same line as the operator they're used by.
+*/
+func (node *TraceNode) String() string {
+
+	var builder strings.Builder
+
+	node.write(&builder, 0)
+	return builder.String()
+}
+
+func (node *TraceNode) write(builder *strings.Builder, depth int) {
+
+	var because []string
+
+	builder.WriteString(strings.Repeat("  ", depth))
+	builder.WriteString(node.Expression)
+
+	switch {
+	case node.ShortCircuited:
+		builder.WriteString(" (short-circuited)\n")
+		return
+	case node.Error != "":
+		builder.WriteString(" → error: " + node.Error)
+	default:
+		builder.WriteString(" → " + formatTraceValue(node.Result))
+	}
+
+	children := node.visibleChildren(nil)
+
+	for _, child := range children {
+		if child.isParameter() {
+			because = append(because, child.Expression+" = "+formatTraceValue(child.Result))
+		}
+	}
+
+	if len(because) > 0 {
+		builder.WriteString(" because " + strings.Join(because, ", "))
+	}
+	builder.WriteString("\n")
+
+	for _, child := range children {
+
+		if child.isParameter() {
+			continue
+		}
+		child.write(builder, depth+1)
+	}
+}
+
+/*
+	Returns the children of this node which are worth showing; those which aren't literals or lambdas.
+	The arguments of a function are shown as if each were a child of the function.
+*/
+func (node *TraceNode) visibleChildren(found []*TraceNode) []*TraceNode {
+
+	for _, child := range node.Children {
+
+		if child.ShortCircuited {
+			found = append(found, child)
+			continue
+		}
+
+		switch child.stage.symbol {
+		case LITERAL, CLOSURE:
+		case SEPARATE:
+			found = child.visibleChildren(found)
+		default:
+			found = append(found, child)
+		}
+	}
+	return found
+}
+
+/*
+	Whether this node is a parameter (or an accessor without arguments) which was read successfully, and so has nothing to show but its value.
+*/
+func (node *TraceNode) isParameter() bool {
+
+	switch node.stage.symbol {
+	case VALUE, ACCESS:
+		return len(node.Children) == 0 && !node.ShortCircuited && node.Error == ""
+	}
+	return false
+}
+
+func formatTraceValue(value interface{}) string {
+
+	switch value := value.(type) {
+
+	case tracedLambda:
+		return string(value)
+
+	case []interface{}:
+		elements := make([]string, len(value))
+		for i, element := range value {
+			elements[i] = formatTraceValue(element)
+		}
+		return "(" + strings.Join(elements, ", ") + ")"
+	}
+	return formatLiteral(value)
+}
@@ -0,0 +1,183 @@
+package govaluate
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+/*
+	Expression wraps an EvaluableExpression so that it's stored as the text it was parsed from,
+	which makes it suitable as a field of configuration structures, or as a database column.
+	Expressions are parsed as soon as they're unmarshaled or scanned, so invalid expressions are found when they're loaded.
+
+	A zero Expression (or one unmarshaled from a JSON null or SQL NULL) has a nil EvaluableExpression.
+*/
+type Expression struct {
+	*EvaluableExpression
+
+	/*
+		The functions available to this expression when it's unmarshaled or scanned.
+		If nil, the functions given to `RegisterFunction` are used instead.
+		To use specific functions, set this before unmarshaling.
+	*/
+	Functions map[string]ExpressionFunction
+}
+
+/*
+	Parses the given [expression] into an Expression, using the functions given to `RegisterFunction`.
+*/
+func ParseExpression(expression string) (Expression, error) {
+
+	var ret Expression
+
+	err := ret.parse(expression)
+	return ret, err
+}
+
+/*
+	Returns the text of the expression, or an empty string if there is none.
+*/
+func (expr Expression) String() string {
+
+	if expr.EvaluableExpression == nil {
+		return ""
+	}
+	return expr.EvaluableExpression.String()
+}
+
+/*
+	Returns the text of the expression, or an empty string if there is none.
+*/
+func (expr Expression) MarshalText() ([]byte, error) {
+	return []byte(expr.String()), nil
+}
+
+/*
+	Parses the given [text] as an expression. Empty text results in a nil EvaluableExpression.
+*/
+func (expr *Expression) UnmarshalText(text []byte) error {
+
+	if len(text) == 0 {
+		expr.EvaluableExpression = nil
+		return nil
+	}
+	return expr.parse(string(text))
+}
+
+/*
+	Returns the text of the expression as a JSON string, or null if there is none.
+*/
+func (expr Expression) MarshalJSON() ([]byte, error) {
+
+	if expr.EvaluableExpression == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(expr.String())
+}
+
+/*
+	Parses the given JSON string as an expression. A JSON null results in a nil EvaluableExpression.
+*/
+func (expr *Expression) UnmarshalJSON(data []byte) error {
+
+	var text *string
+
+	err := json.Unmarshal(data, &text)
+	if err != nil {
+		return fmt.Errorf("Expression must be a JSON string: %v", err)
+	}
+
+	if text == nil {
+		expr.EvaluableExpression = nil
+		return nil
+	}
+	return expr.parse(*text)
+}
+
+/*
+	Serializes the expression with `EvaluableExpression.MarshalBinary`, or to no data at all if there is none.
+	This is defined here, rather than being promoted from the EvaluableExpression, so that a zero Expression can be marshaled.
+*/
+func (expr Expression) MarshalBinary() ([]byte, error) {
+
+	if expr.EvaluableExpression == nil {
+		return []byte{}, nil
+	}
+	return expr.EvaluableExpression.MarshalBinary()
+}
+
+/*
+	Deserializes an expression produced by `MarshalBinary`, using the same functions as `UnmarshalText`.
+	No data results in a nil EvaluableExpression.
+*/
+func (expr *Expression) UnmarshalBinary(data []byte) error {
+
+	if len(data) == 0 {
+		expr.EvaluableExpression = nil
+		return nil
+	}
+
+	functions := expr.functions()
+	resolver := func(name string) (ExpressionFunction, bool) {
+		function, found := functions[name]
+		return function, found
+	}
+
+	loaded, err := UnmarshalEvaluableExpression(data, resolver)
+	if err != nil {
+		return err
+	}
+
+	expr.EvaluableExpression = loaded
+	return nil
+}
+
+/*
+	Implements `sql.Scanner`, parsing the expression from a text column.
+*/
+func (expr *Expression) Scan(value interface{}) error {
+
+	switch value := value.(type) {
+
+	case nil:
+		expr.EvaluableExpression = nil
+		return nil
+	case string:
+		return expr.parse(value)
+	case []byte:
+		return expr.parse(string(value))
+	}
+
+	return fmt.Errorf("Unable to scan expression from value of type %T", value)
+}
+
+/*
+	Implements `driver.Valuer`, storing the text of the expression, or NULL if there is none.
+*/
+func (expr Expression) Value() (driver.Value, error) {
+
+	if expr.EvaluableExpression == nil {
+		return nil, nil
+	}
+	return expr.String(), nil
+}
+
+func (expr *Expression) parse(text string) error {
+
+	parsed, err := NewEvaluableExpressionWithFunctions(text, expr.functions())
+	if err != nil {
+		return fmt.Errorf("Unable to parse expression '%s': %v", text, err)
+	}
+
+	expr.EvaluableExpression = parsed
+	return nil
+}
+
+func (expr *Expression) functions() map[string]ExpressionFunction {
+
+	if expr.Functions == nil {
+		return getRegisteredFunctions()
+	}
+	return expr.Functions
+}
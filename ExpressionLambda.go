@@ -0,0 +1,36 @@
+package govaluate
+
+/*
+	Represents an anonymous function written inline in an expression, such as `x => x.Price > 100`.
+	Evaluating a lambda does not run its body; it produces an ExpressionLambda, which can be passed to functions.
+	The builtin functions (`any`, `all`, `filter`, `map`, `count`, `sum`) accept lambdas, and user-defined
+	ExpressionFunctions may accept them as well, invoking them through `Call`.
+*/
+type ExpressionLambda struct {
+	parameter  string
+	body       *evaluationStage
+	expression EvaluableExpression
+	parameters Parameters
+}
+
+/*
+	Runs the body of this lambda with its parameter set to the given [argument].
+	Any other parameters used by the body are resolved from the parameters the lambda was defined with.
+*/
+func (lambda ExpressionLambda) Call(argument interface{}) (interface{}, error) {
+
+	parameters := scopedParameters{
+		name:   lambda.parameter,
+		value:  castToFloat64(argument),
+		parent: lambda.parameters,
+	}
+
+	return lambda.expression.evaluateStage(lambda.body, parameters)
+}
+
+/*
+	Returns the name of the parameter which this lambda binds when called.
+*/
+func (lambda ExpressionLambda) Parameter() string {
+	return lambda.parameter
+}
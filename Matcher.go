@@ -0,0 +1,363 @@
+package govaluate
+
+import (
+	"sort"
+)
+
+/*
+	Matcher evaluates many rules against the same parameters, like a RuleSet, but uses an index to skip rules which can't fire.
+
+	Rules are indexed by the comparisons of a parameter to a literal which they require to be true; that is, comparisons joined
+	to the rest of the rule by `&&`, such as `country == 'US'`, `plan in ('pro', 'enterprise')`, or `age > 18`.
+	For each evaluation, only the rules whose indexed comparison is true (and those which have none) are evaluated.
+	Equality and `in` are used first, since they narrow down rules the most, then numeric ranges.
+
+	Since rules which can't fire aren't evaluated, they also don't report errors that they may have otherwise,
+	such as for a missing parameter which comes after the indexed comparison.
+	Otherwise, `Match` gives exactly the same results as `RuleSet.Eval`.
+*/
+type Matcher struct {
+	rules *RuleSet
+
+	// rules which have no comparison that can be indexed, so are always evaluated.
+	unindexed []int
+
+	// rules which require a parameter to be one of some values, by the name of the parameter, then by each of those values.
+	equalities map[string]map[interface{}][]int
+
+	// rules which require a numeric parameter to be above or below some value, by the name of the parameter, sorted by that value.
+	lowerBounds map[string][]ruleBound
+	upperBounds map[string][]ruleBound
+}
+
+type ruleBound struct {
+	value     float64
+	inclusive bool
+	rule      int
+}
+
+/*
+	A comparison of a parameter to literals, found in a rule.
+	The parameter is always on the left of the [symbol], even if it was written on the right.
+*/
+type rulePredicate struct {
+	name   string
+	symbol OperatorSymbol
+	values []interface{}
+}
+
+/*
+	Compiles the given [rules] with the given [env] (or the defaults of `NewEnv`, if it's nil) into a Matcher.
+	Returns an error if any rule fails to compile, or if two rules have the same name.
+*/
+func NewMatcher(env *Env, rules []Rule) (*Matcher, error) {
+
+	ruleSet, err := NewRuleSet(env, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &Matcher{
+		rules:       ruleSet,
+		equalities:  make(map[string]map[interface{}][]int),
+		lowerBounds: make(map[string][]ruleBound),
+		upperBounds: make(map[string][]ruleBound),
+	}
+
+	for i, rule := range ruleSet.rules {
+		ret.index(i, findPredicates(rule.expression.evaluationStages, nil))
+	}
+
+	for _, bounds := range ret.lowerBounds {
+		sortBounds(bounds)
+	}
+	for _, bounds := range ret.upperBounds {
+		sortBounds(bounds)
+	}
+	return ret, nil
+}
+
+/*
+	Same as `Match`, but automatically wraps a map of parameters into a `govalute.Parameters` structure.
+*/
+func (matcher *Matcher) Evaluate(parameters map[string]interface{}, mode RuleSetMode) ([]RuleResult, error) {
+	if parameters == nil {
+		return matcher.Match(nil, mode)
+	}
+	return matcher.Match(MapParameters(parameters), mode)
+}
+
+/*
+	Evaluates the rules which may fire for the given [parameters], in order of priority,
+	and returns those which fired along with their results. See `RuleSet.Eval`.
+*/
+func (matcher *Matcher) Match(parameters Parameters, mode RuleSetMode) ([]RuleResult, error) {
+
+	cache := matcher.rules.newCache(parameters)
+	defer matcher.rules.releaseCache(cache)
+
+	candidates := make([]bool, len(matcher.rules.rules))
+
+	for _, rule := range matcher.unindexed {
+		candidates[rule] = true
+	}
+
+	for name, index := range matcher.equalities {
+
+		value, err := cache.Get(name)
+
+		// rules which would fail are evaluated, so that they report it.
+		if err != nil {
+			for _, rules := range index {
+				markCandidates(candidates, rules)
+			}
+			continue
+		}
+
+		switch value.(type) {
+		case float64, string, bool:
+			markCandidates(candidates, index[value])
+		}
+	}
+
+	for name, bounds := range matcher.lowerBounds {
+		markBounds(candidates, cache, name, bounds, true)
+	}
+
+	for name, bounds := range matcher.upperBounds {
+		markBounds(candidates, cache, name, bounds, false)
+	}
+
+	return matcher.rules.evaluate(cache, candidates, mode)
+}
+
+/*
+	Adds the rule at the given index to the index of this matcher, by the most selective of its [predicates].
+*/
+func (matcher *Matcher) index(rule int, predicates []rulePredicate) {
+
+	for _, predicate := range predicates {
+
+		if predicate.symbol != EQ && predicate.symbol != IN {
+			continue
+		}
+
+		values, found := matcher.equalities[predicate.name]
+		if !found {
+			values = make(map[interface{}][]int)
+			matcher.equalities[predicate.name] = values
+		}
+
+		for _, value := range predicate.values {
+			values[value] = append(values[value], rule)
+		}
+		return
+	}
+
+	for _, predicate := range predicates {
+
+		bound := ruleBound{
+			value:     predicate.values[0].(float64),
+			inclusive: predicate.symbol == GTE || predicate.symbol == LTE,
+			rule:      rule,
+		}
+
+		switch predicate.symbol {
+		case GT, GTE:
+			matcher.lowerBounds[predicate.name] = append(matcher.lowerBounds[predicate.name], bound)
+		case LT, LTE:
+			matcher.upperBounds[predicate.name] = append(matcher.upperBounds[predicate.name], bound)
+		}
+		return
+	}
+
+	matcher.unindexed = append(matcher.unindexed, rule)
+}
+
+func markCandidates(candidates []bool, rules []int) {
+	for _, rule := range rules {
+		candidates[rule] = true
+	}
+}
+
+/*
+	Marks the rules whose bound is satisfied by the value of the parameter of the given [name].
+	If [lower] is true, the bounds are minimums, otherwise they're maximums.
+*/
+func markBounds(candidates []bool, parameters Parameters, name string, bounds []ruleBound, lower bool) {
+
+	value, err := parameters.Get(name)
+	number, ok := value.(float64)
+
+	// rules which would fail (or which compare strings) are evaluated, so that they report it.
+	if err != nil || !ok {
+		for _, bound := range bounds {
+			candidates[bound.rule] = true
+		}
+		return
+	}
+
+	// the first bound which isn't strictly below the value.
+	start := sort.Search(len(bounds), func(i int) bool {
+		return bounds[i].value >= number
+	})
+
+	// bounds equal to the value are satisfied only if they're inclusive.
+	end := start
+	for end < len(bounds) && bounds[end].value == number {
+		if bounds[end].inclusive {
+			candidates[bounds[end].rule] = true
+		}
+		end++
+	}
+
+	if lower {
+		for _, bound := range bounds[:start] {
+			candidates[bound.rule] = true
+		}
+		return
+	}
+
+	for _, bound := range bounds[end:] {
+		candidates[bound.rule] = true
+	}
+}
+
+func sortBounds(bounds []ruleBound) {
+	sort.SliceStable(bounds, func(i, j int) bool {
+		return bounds[i].value < bounds[j].value
+	})
+}
+
+/*
+	Finds the comparisons of parameters to literals which must all be true for the given [stage] to be true.
+*/
+func findPredicates(stage *evaluationStage, found []rulePredicate) []rulePredicate {
+
+	stage = unwrapNoop(stage)
+	if stage == nil {
+		return found
+	}
+
+	if stage.symbol == AND {
+		found = findPredicates(stage.leftStage, found)
+		return findPredicates(stage.rightStage, found)
+	}
+
+	predicate, ok := findPredicate(stage)
+	if ok {
+		found = append(found, predicate)
+	}
+	return found
+}
+
+func findPredicate(stage *evaluationStage) (rulePredicate, bool) {
+
+	var ret rulePredicate
+
+	left := unwrapNoop(stage.leftStage)
+	right := unwrapNoop(stage.rightStage)
+	symbol := stage.symbol
+
+	if left == nil || right == nil {
+		return ret, false
+	}
+
+	// comparisons written with the literal first are turned around.
+	if left.symbol == LITERAL && symbol != IN {
+
+		left, right = right, left
+
+		switch symbol {
+		case GT:
+			symbol = LT
+		case GTE:
+			symbol = LTE
+		case LT:
+			symbol = GT
+		case LTE:
+			symbol = GTE
+		}
+	}
+
+	if left.symbol != VALUE || left.reference == "" {
+		return ret, false
+	}
+
+	ret.name = left.reference
+	ret.symbol = symbol
+
+	switch symbol {
+
+	case EQ, IN:
+		// a list is only compared by `in`, and `in` fails with anything but a list.
+		if (symbol == EQ) != (right.symbol == LITERAL) {
+			return ret, false
+		}
+
+		ret.values = findLiterals(right, nil)
+		if ret.values == nil {
+			return ret, false
+		}
+
+		for _, value := range ret.values {
+			switch value.(type) {
+			case float64, string, bool:
+			default:
+				return ret, false
+			}
+		}
+
+	case GT, GTE, LT, LTE:
+		if right.symbol != LITERAL {
+			return ret, false
+		}
+
+		value, err := right.operator(nil, nil, nil)
+		if _, ok := value.(float64); err != nil || !ok {
+			return ret, false
+		}
+		ret.values = []interface{}{value}
+
+	default:
+		return ret, false
+	}
+	return ret, true
+}
+
+/*
+	Returns the values of the given [stage], if it's a literal or list of literals. Otherwise returns nil.
+*/
+func findLiterals(stage *evaluationStage, found []interface{}) []interface{} {
+
+	stage = unwrapNoop(stage)
+	if stage == nil {
+		return nil
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, err := stage.operator(nil, nil, nil)
+		if err != nil {
+			return nil
+		}
+		return append(found, value)
+
+	case SEPARATE:
+		found = findLiterals(stage.leftStage, found)
+		if found == nil {
+			return nil
+		}
+		return findLiterals(stage.rightStage, found)
+	}
+	return nil
+}
+
+func unwrapNoop(stage *evaluationStage) *evaluationStage {
+
+	for stage != nil && stage.symbol == NOOP && stage.leftStage == nil {
+		stage = stage.rightStage
+	}
+	return stage
+}
@@ -2,7 +2,6 @@ package govaluate
 
 /*
 	Represents the valid symbols for operators.
-
 */
 type OperatorSymbol int
 
@@ -47,6 +46,8 @@ const (
 	FUNCTIONAL
 	ACCESS
 	SEPARATE
+	CLOSURE
+	BIND
 )
 
 type operatorPrecedence int
@@ -65,7 +66,9 @@ const (
 	ternaryPrecedence
 	logicalAndPrecedence
 	logicalOrPrecedence
+	closurePrecedence
 	separatePrecedence
+	bindPrecedence
 )
 
 func findOperatorPrecedenceForSymbol(symbol OperatorSymbol) operatorPrecedence {
@@ -96,8 +99,12 @@ func findOperatorPrecedenceForSymbol(symbol OperatorSymbol) operatorPrecedence {
 		return ternaryPrecedence
 	case ACCESS, FUNCTIONAL:
 		return functionalPrecedence
+	case CLOSURE:
+		return closurePrecedence
 	case SEPARATE:
 		return separatePrecedence
+	case BIND:
+		return bindPrecedence
 	}
 
 	return valuePrecedence
@@ -182,6 +189,10 @@ var separatorSymbols = map[string]OperatorSymbol{
 	",": SEPARATE,
 }
 
+var lambdaSymbols = map[string]OperatorSymbol{
+	"=>": CLOSURE,
+}
+
 /*
 	Returns true if this operator is contained by the given array of candidate symbols.
 	False otherwise.
@@ -263,6 +274,10 @@ func (s OperatorSymbol) String() string {
 		return ":"
 	case COALESCE:
 		return "??"
+	case CLOSURE:
+		return "=>"
+	case BIND:
+		return "let"
 	}
 	return ""
 }
@@ -0,0 +1,371 @@
+package govaluate
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+/*
+	The most strings a range keeps track of individually; any more, and it's taken to be any string.
+*/
+const maxRangeStrings = 64
+
+/*
+	The values which a parameter may have, or which an expression may evaluate to, as used by `AnalyzeRange`.
+	A range may hold values of several types at once, such as a parameter which is either a number or null.
+	The zero value is an empty range, which holds nothing at all.
+*/
+type Range struct {
+
+	/*
+		True if the range holds numbers, in which case it holds those from [Min] to [Max] (inclusive), which may be infinite.
+		If [Integer] is also true, it only holds the whole numbers between them.
+	*/
+	Number   bool
+	Min, Max float64
+	Integer  bool
+
+	/*
+		The strings which the range holds, or if [AnyString] is true, every string.
+	*/
+	Strings   []string
+	AnyString bool
+
+	True, False bool
+	Nil         bool
+
+	/*
+		True if the range holds values which are none of the above, such as times, arrays, and structs.
+	*/
+	Other bool
+}
+
+/*
+	Returns a range of the numbers from [min] to [max], inclusive. Either may be infinite.
+*/
+func NumberRange(min, max float64) Range {
+	return makeNumberRange(min, max, false)
+}
+
+/*
+	Returns a range of the whole numbers from [min] to [max], inclusive. Either may be infinite.
+*/
+func IntegerRange(min, max float64) Range {
+	return makeNumberRange(min, max, true)
+}
+
+/*
+	Returns a range of the given strings, or of every string if none are given.
+*/
+func StringRange(values ...string) Range {
+
+	if len(values) == 0 {
+		return Range{AnyString: true}
+	}
+	return Range{}.Union(Range{Strings: values})
+}
+
+/*
+	Returns a range of both true and false.
+*/
+func BoolRange() Range {
+	return Range{True: true, False: true}
+}
+
+/*
+	Returns a range which holds every value.
+*/
+func AnyRange() Range {
+	return Range{
+		Number:    true,
+		Min:       math.Inf(-1),
+		Max:       math.Inf(1),
+		AnyString: true,
+		True:      true,
+		False:     true,
+		Nil:       true,
+		Other:     true,
+	}
+}
+
+/*
+	Returns a range which holds the values of both this range and the [other].
+	Numbers are joined into a single span, so the union of [0, 1] and [3, 4] is [0, 4].
+*/
+func (r Range) Union(other Range) Range {
+
+	ret := Range{
+		Number:    r.Number || other.Number,
+		AnyString: r.AnyString || other.AnyString,
+		True:      r.True || other.True,
+		False:     r.False || other.False,
+		Nil:       r.Nil || other.Nil,
+		Other:     r.Other || other.Other,
+	}
+
+	switch {
+	case r.Number && other.Number:
+		ret.Min = math.Min(r.Min, other.Min)
+		ret.Max = math.Max(r.Max, other.Max)
+		ret.Integer = r.Integer && other.Integer
+	case r.Number:
+		ret.Min, ret.Max, ret.Integer = r.Min, r.Max, r.Integer
+	case other.Number:
+		ret.Min, ret.Max, ret.Integer = other.Min, other.Max, other.Integer
+	}
+
+	if !ret.AnyString {
+		ret.Strings = joinStrings(r.Strings, other.Strings)
+		if len(ret.Strings) > maxRangeStrings {
+			ret.Strings = nil
+			ret.AnyString = true
+		}
+	}
+	return ret
+}
+
+/*
+	Returns true if this range holds no values at all, such as the range of an expression which always fails.
+*/
+func (r Range) IsEmpty() bool {
+	return !r.Number && !r.hasStrings() && !r.True && !r.False && !r.Nil && !r.Other
+}
+
+/*
+	Returns a description of this range, such as `[0, 1] | null`.
+*/
+func (r Range) String() string {
+
+	var parts []string
+
+	if r.Number {
+		span := "[" + formatLiteral(r.Min) + ", " + formatLiteral(r.Max) + "]"
+		if r.Integer {
+			span = "integers in " + span
+		}
+		parts = append(parts, span)
+	}
+
+	if r.AnyString {
+		parts = append(parts, "any string")
+	}
+	for _, value := range r.Strings {
+		parts = append(parts, quoteString(value))
+	}
+
+	if r.True {
+		parts = append(parts, "true")
+	}
+	if r.False {
+		parts = append(parts, "false")
+	}
+	if r.Nil {
+		parts = append(parts, "null")
+	}
+	if r.Other {
+		parts = append(parts, "other")
+	}
+
+	if len(parts) == 0 {
+		return "nothing"
+	}
+	return strings.Join(parts, " | ")
+}
+
+/*
+	Returns a range which holds only the values which are in both this range and the [other].
+*/
+func (r Range) intersect(other Range) Range {
+
+	ret := Range{
+		True:  r.True && other.True,
+		False: r.False && other.False,
+		Nil:   r.Nil && other.Nil,
+		Other: r.Other && other.Other,
+	}
+
+	if r.Number && other.Number {
+		numbers := makeNumberRange(math.Max(r.Min, other.Min), math.Min(r.Max, other.Max), r.Integer || other.Integer)
+		ret.Number, ret.Min, ret.Max, ret.Integer = numbers.Number, numbers.Min, numbers.Max, numbers.Integer
+	}
+
+	switch {
+	case r.AnyString && other.AnyString:
+		ret.AnyString = true
+	case r.AnyString:
+		ret.Strings = other.Strings
+	case other.AnyString:
+		ret.Strings = r.Strings
+	default:
+		for _, value := range r.Strings {
+			if containsString(other.Strings, value) {
+				ret.Strings = append(ret.Strings, value)
+			}
+		}
+	}
+	return ret
+}
+
+/*
+	Returns this range, without the given [value]. Numbers can only be removed from the ends of a range of integers,
+	or from a range of that number alone.
+*/
+func (r Range) exclude(value interface{}) Range {
+
+	switch value := value.(type) {
+
+	case float64:
+		if !r.Number {
+			break
+		}
+		if r.Min == value && r.Max == value {
+			r.Number = false
+			break
+		}
+		if r.Integer {
+			min, max := r.Min, r.Max
+			if min == value {
+				min++
+			}
+			if max == value {
+				max--
+			}
+			numbers := makeNumberRange(min, max, true)
+			r.Number, r.Min, r.Max = numbers.Number, numbers.Min, numbers.Max
+		}
+
+	case string:
+		var kept []string
+		for _, existing := range r.Strings {
+			if existing != value {
+				kept = append(kept, existing)
+			}
+		}
+		r.Strings = kept
+
+	case bool:
+		if value {
+			r.True = false
+		} else {
+			r.False = false
+		}
+
+	case nil:
+		r.Nil = false
+	}
+	return r
+}
+
+func (r Range) withoutNil() Range {
+	r.Nil = false
+	return r
+}
+
+func (r Range) hasStrings() bool {
+	return r.AnyString || len(r.Strings) > 0
+}
+
+/*
+	Returns the only value this range holds, if it holds exactly one (and it's one which can be known exactly).
+*/
+func (r Range) single() (interface{}, bool) {
+
+	var ret interface{}
+	count := 0
+
+	if r.Number {
+		count++
+		ret = r.Min
+		if r.Min != r.Max {
+			count++
+		}
+	}
+	if r.AnyString || r.Other {
+		count += 2
+	}
+	for _, value := range r.Strings {
+		count++
+		ret = value
+	}
+	if r.True {
+		count++
+		ret = true
+	}
+	if r.False {
+		count++
+		ret = false
+	}
+	if r.Nil {
+		count++
+		ret = nil
+	}
+	return ret, count == 1
+}
+
+/*
+	Returns the range of just the given [value].
+*/
+func rangeOf(value interface{}) Range {
+
+	switch value := value.(type) {
+	case nil:
+		return Range{Nil: true}
+	case float64:
+		return makeNumberRange(value, value, value == math.Trunc(value))
+	case string:
+		return Range{Strings: []string{value}}
+	case bool:
+		return Range{True: value, False: !value}
+	}
+	return Range{Other: true}
+}
+
+/*
+	Returns a range of the numbers from [min] to [max], which is empty if there are none.
+	If either is NaN, it's the range of every number.
+*/
+func makeNumberRange(min, max float64, integer bool) Range {
+
+	if math.IsNaN(min) || math.IsNaN(max) {
+		min, max, integer = math.Inf(-1), math.Inf(1), false
+	}
+
+	if integer {
+		min, max = math.Ceil(min), math.Floor(max)
+	}
+
+	// adding zero turns -0 into 0.
+	min, max = min+0, max+0
+
+	if min > max {
+		return Range{}
+	}
+	return Range{Number: true, Min: min, Max: max, Integer: integer}
+}
+
+func joinStrings(left, right []string) []string {
+
+	var ret []string
+
+	for _, values := range [][]string{left, right} {
+		for _, value := range values {
+			if !containsString(ret, value) {
+				ret = append(ret, value)
+			}
+		}
+	}
+
+	sort.Strings(ret)
+	return ret
+}
+
+func containsString(values []string, value string) bool {
+
+	for _, existing := range values {
+		if existing == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package govaluate
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+/*
+	A single named expression, to be compiled as part of a RuleSet.
+*/
+type Rule struct {
+	Name       string
+	Expression string
+
+	/*
+		Rules of higher priority are evaluated first. Rules of the same priority are evaluated in the order they were given.
+	*/
+	Priority int
+
+	/*
+		Hooks called when this rule is evaluated, in addition to those of the Env it's compiled with.
+		Since parameters are shared by all rules, parameter lookups are only given to the hooks of the Env.
+	*/
+	Hooks EvaluationHooks
+}
+
+/*
+	Determines which rules a RuleSet evaluates.
+*/
+type RuleSetMode int
+
+const (
+	// evaluates every rule, and returns all which fired.
+	MatchAll RuleSetMode = iota
+
+	// stops at the first rule which fires.
+	MatchFirst
+)
+
+/*
+	A rule which fired, and the value it evaluated to.
+*/
+type RuleResult struct {
+	Name   string
+	Result interface{}
+}
+
+/*
+	RuleSet evaluates many expressions against the same parameters. This is faster than evaluating each on its own,
+	since parts of the expressions which are identical are only evaluated once, and each parameter is only read once,
+	no matter how many of the rules use it.
+
+	A rule fires if it evaluates to anything other than false or nil.
+	A RuleSet can be evaluated concurrently, as long as the functions its rules use can be.
+*/
+type RuleSet struct {
+	rules []compiledRule
+
+	// the number of results of shared stages which are kept during each evaluation.
+	slots int
+
+	// the hooks of the Env the rules were compiled with, which are given parameter lookups.
+	hooks EvaluationHooks
+
+	// caches which have been used before, so that the results of each evaluation don't need to be allocated.
+	caches sync.Pool
+}
+
+type compiledRule struct {
+	name       string
+	expression *EvaluableExpression
+}
+
+/*
+	Compiles the given [rules] with the given [env] (or the defaults of `NewEnv`, if it's nil) into a RuleSet.
+	Returns an error if any rule fails to compile, or if two rules have the same name.
+*/
+func NewRuleSet(env *Env, rules []Rule) (*RuleSet, error) {
+
+	var roots []*evaluationStage
+
+	if env == nil {
+		env = NewEnv()
+	}
+
+	ordered := make([]Rule, len(rules))
+	copy(ordered, rules)
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+
+	ret := &RuleSet{
+		rules: make([]compiledRule, len(ordered)),
+		hooks: env.Hooks,
+	}
+	names := make(map[string]bool)
+
+	for i, rule := range ordered {
+
+		if names[rule.Name] {
+			return nil, fmt.Errorf("Rule '%s' is defined more than once", rule.Name)
+		}
+		names[rule.Name] = true
+
+		expression, err := env.Compile(rule.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to compile rule '%s': %w", rule.Name, err)
+		}
+
+		expression.Hooks = chainHooks(expression.Hooks, rule.Hooks)
+
+		ret.rules[i] = compiledRule{
+			name:       rule.Name,
+			expression: expression,
+		}
+		roots = append(roots, expression.evaluationStages)
+	}
+
+	ret.slots = shareSubexpressions(roots, 0, env.isPure)
+
+	// the stages of each rule may now be shared with other rules, so they're kept in the same slots.
+	for _, rule := range ret.rules {
+		rule.expression.sharedSlots = ret.slots
+	}
+	return ret, nil
+}
+
+/*
+	Same as `Eval`, but automatically wraps a map of parameters into a `govalute.Parameters` structure.
+*/
+func (rules *RuleSet) Evaluate(parameters map[string]interface{}, mode RuleSetMode) ([]RuleResult, error) {
+	if parameters == nil {
+		return rules.Eval(nil, mode)
+	}
+	return rules.Eval(MapParameters(parameters), mode)
+}
+
+/*
+	Evaluates the rules of this set in order of priority, using the given [parameters],
+	and returns those which fired along with their results, in the order they were evaluated.
+	With `MatchFirst`, at most one rule is returned, and the rules after it aren't evaluated.
+	If any rule fails to evaluate, returns an error naming it.
+*/
+func (rules *RuleSet) Eval(parameters Parameters, mode RuleSetMode) ([]RuleResult, error) {
+
+	cache := rules.newCache(parameters)
+	defer rules.releaseCache(cache)
+
+	return rules.evaluate(cache, nil, mode)
+}
+
+/*
+	Returns what the rules of this set are evaluated with, which keeps the results of shared stages and parameters
+	for the duration of one evaluation. It must be given to `releaseCache` once the evaluation is done.
+*/
+func (rules *RuleSet) newCache(parameters Parameters) *cachedParameters {
+
+	if parameters == nil {
+		parameters = DUMMY_PARAMETERS
+	}
+
+	if rules.hooks != nil {
+		parameters = hookedParameters{parameters, rules.hooks}
+	}
+
+	cache, _ := rules.caches.Get().(*cachedParameters)
+	if cache == nil {
+		cache = newCachedParameters(nil, rules.slots)
+	}
+
+	cache.Parameters = &sanitizedParameters{newMemoizedParameters(parameters)}
+	return cache
+}
+
+func (rules *RuleSet) releaseCache(cache *cachedParameters) {
+
+	for i := range cache.results {
+		cache.results[i] = cachedResult{}
+	}
+
+	cache.Parameters = nil
+	rules.caches.Put(cache)
+}
+
+/*
+	Evaluates the rules of this set with the given [cache]. If [candidates] isn't nil, only the rules whose index in it is true are evaluated.
+*/
+func (rules *RuleSet) evaluate(cache *cachedParameters, candidates []bool, mode RuleSetMode) ([]RuleResult, error) {
+
+	var ret []RuleResult
+
+	for i, rule := range rules.rules {
+
+		if rule.expression.evaluationStages == nil || (candidates != nil && !candidates[i]) {
+			continue
+		}
+
+		result, err := rule.expression.evaluateStage(rule.expression.evaluationStages, cache)
+		if err != nil {
+			err = fmt.Errorf("Unable to evaluate rule '%s': %w", rule.name, err)
+			if rule.expression.Hooks != nil {
+				rule.expression.Hooks.Error(err)
+			}
+			return nil, err
+		}
+
+		if result == false || isNil(result) {
+			continue
+		}
+
+		ret = append(ret, RuleResult{
+			Name:   rule.name,
+			Result: result,
+		})
+
+		if mode == MatchFirst {
+			break
+		}
+	}
+	return ret, nil
+}
@@ -28,6 +28,12 @@ const (
 	CLAUSE_CLOSE
 
 	TERNARY
+	LAMBDA
+
+	LET
+	TERMINATOR
+
+	NULL
 )
 
 /*
@@ -70,6 +76,14 @@ func (kind TokenKind) String() string {
 		return "TERNARY"
 	case ACCESSOR:
 		return "ACCESSOR"
+	case LAMBDA:
+		return "LAMBDA"
+	case LET:
+		return "LET"
+	case TERMINATOR:
+		return "TERMINATOR"
+	case NULL:
+		return "NULL"
 	}
 
 	return "UNKNOWN"
@@ -0,0 +1,119 @@
+package govaluate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvalBatch(test *testing.T) {
+
+	expression, err := NewEvaluableExpression("price * amount")
+	if err != nil {
+		test.Logf("Failed to parse expression: %v", err)
+		test.FailNow()
+	}
+
+	batch := MapParameterSlice{
+		{"price": 2, "amount": 3},
+		{"price": 1.5, "amount": 2},
+		{"price": 10, "amount": 0},
+	}
+
+	for _, workers := range []int{0, 1, 2, 8} {
+
+		results, err := expression.EvalBatch(batch, workers)
+		if err != nil {
+			test.Logf("Failed to evaluate batch with %d workers: %v", workers, err)
+			test.Fail()
+			continue
+		}
+
+		expected := []interface{}{6.0, 3.0, 0.0}
+		if !reflect.DeepEqual(results, expected) {
+			test.Logf("Expected %v with %d workers, got %v", expected, workers, results)
+			test.Fail()
+		}
+	}
+}
+
+func TestFilter(test *testing.T) {
+
+	expression, err := NewEvaluableExpression("age >= 18 && country == 'NL'")
+	if err != nil {
+		test.Logf("Failed to parse expression: %v", err)
+		test.FailNow()
+	}
+
+	columns := ColumnParameters{
+		"age":     {12, 18, 40, 65, 30, 17, 90},
+		"country": {"NL", "NL", "DE", "NL", "NL", "NL", "BE"},
+	}
+	expected := []int{1, 3, 4}
+
+	for _, workers := range []int{1, 3, 100} {
+
+		matches, err := expression.Filter(columns, workers)
+		if err != nil {
+			test.Logf("Failed to filter columns with %d workers: %v", workers, err)
+			test.Fail()
+			continue
+		}
+
+		if !reflect.DeepEqual(matches, expected) {
+			test.Logf("Expected %v with %d workers, got %v", expected, workers, matches)
+			test.Fail()
+		}
+	}
+
+	// rows can also be given one at a time.
+	var found []int
+	index := 0
+	length := columns.Len()
+
+	err = expression.EvalEach(
+		func() (Parameters, bool) {
+			index++
+			return columns.Row(index - 1), index <= length
+		},
+		func(index int, result interface{}) error {
+			if result == true {
+				found = append(found, index)
+			}
+			return nil
+		})
+
+	if err != nil || !reflect.DeepEqual(found, expected) {
+		test.Logf("Expected %v from iterating rows, got %v (error %v)", expected, found, err)
+		test.Fail()
+	}
+}
+
+func TestBatchFailures(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("foo > 1")
+
+	batch := ParameterSlice{
+		MapParameters{"foo": 2},
+		MapParameters{"foo": 0},
+		MapParameters{"bar": 2},
+		MapParameters{"foo": "bar"},
+	}
+
+	for _, workers := range []int{1, 4} {
+
+		_, err := expression.Filter(batch, workers)
+		if err == nil || !strings.HasPrefix(err.Error(), "Unable to evaluate row 2: No parameter 'foo' found.") {
+			test.Logf("Expected the error of the first failing row with %d workers, got %v", workers, err)
+			test.Fail()
+		}
+	}
+
+	expression, _ = NewEvaluableExpression("foo + 1")
+
+	_, err := expression.Filter(batch[:1], 1)
+	if err == nil || !strings.Contains(err.Error(), "cannot be used to filter, it is not a bool") {
+		test.Logf("Expected an error filtering by a number, got %v", err)
+		test.Fail()
+	}
+}
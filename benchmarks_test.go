@@ -39,6 +39,24 @@ func BenchmarkFullParse(bench *testing.B) {
 	}
 }
 
+/*
+	Benchmarks loading the same expression as BenchmarkFullParse from its serialized form, rather than parsing it.
+*/
+func BenchmarkFullUnmarshal(bench *testing.B) {
+	expression, _ := NewEvaluableExpression("2 > 1 &&" +
+		"'something' != 'nothing' || " +
+		"'2014-01-20' < 'Wed Jul  8 23:07:35 MDT 2015' && " +
+		"[escapedVariable name with spaces] <= unescaped\\-variableName &&" +
+		"modifierTest + 1000 / 2 > (80 * 100 % 2)")
+
+	data, _ := expression.MarshalBinary()
+
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		_, _ = UnmarshalEvaluableExpression(data, nil)
+	}
+}
+
 /*
   Benchmarks the bare-minimum evaluation time
 */
@@ -213,3 +231,21 @@ func BenchmarkNestedAccessors(bench *testing.B) {
 		_, _ = expression.Evaluate(fooFailureParameters)
 	}
 }
+
+/*
+	Benchmarks filtering many rows at once, with the same expression as BenchmarkEvaluationParametersModifiers.
+	Each op is one row.
+*/
+func BenchmarkFilter(bench *testing.B) {
+	expression, _ := NewEvaluableExpression("(requests_made * requests_succeeded / 100) >= 90")
+	batch := make(MapParameterSlice, bench.N)
+	for i := range batch {
+		batch[i] = map[string]interface{}{
+			"requests_made":      float64(i % 200),
+			"requests_succeeded": 90.0,
+		}
+	}
+
+	bench.ResetTimer()
+	_, _ = expression.Filter(batch, 1)
+}
@@ -0,0 +1,274 @@
+package govaluate
+
+import (
+	"fmt"
+	"reflect"
+)
+
+/*
+	Functions which are available to every expression without being passed to `NewEvaluableExpressionWithFunctions`.
+	A user-defined function of the same name always takes priority over a builtin.
+	Builtins are only recognized when they are called (that is, followed by parenthesis), so parameters may share their names.
+*/
+var builtinFunctions = map[string]ExpressionFunction{
+	"any":    anyFunction,
+	"all":    allFunction,
+	"filter": filterFunction,
+	"map":    mapFunction,
+	"count":  countFunction,
+	"sum":    sumFunction,
+}
+
+/*
+	Returns true if the lambda returns true for at least one element of the array.
+	Stops calling the lambda as soon as one does.
+*/
+func anyFunction(arguments ...interface{}) (interface{}, error) {
+
+	items, lambda, err := readLambdaArguments("any", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+
+		matched, err := callPredicate("any", lambda, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+/*
+	Returns true if the lambda returns true for every element of the array.
+	Stops calling the lambda as soon as one does not.
+*/
+func allFunction(arguments ...interface{}) (interface{}, error) {
+
+	items, lambda, err := readLambdaArguments("all", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+
+		matched, err := callPredicate("all", lambda, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+/*
+	Returns a new array of only the elements for which the lambda returns true.
+*/
+func filterFunction(arguments ...interface{}) (interface{}, error) {
+
+	items, lambda, err := readLambdaArguments("filter", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]interface{}, 0, len(items))
+	for _, item := range items {
+
+		matched, err := callPredicate("filter", lambda, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if matched {
+			ret = append(ret, item)
+		}
+	}
+	return ret, nil
+}
+
+/*
+	Returns a new array containing the result of the lambda for each element.
+*/
+func mapFunction(arguments ...interface{}) (interface{}, error) {
+
+	items, lambda, err := readLambdaArguments("map", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]interface{}, len(items))
+	for i, item := range items {
+
+		ret[i], err = lambda.Call(item)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+/*
+	Returns the number of elements in the array.
+	If a lambda is given, only elements for which the lambda returns true are counted.
+*/
+func countFunction(arguments ...interface{}) (interface{}, error) {
+
+	items, lambda, err := readArrayArguments("count", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	if lambda == nil {
+		return float64(len(items)), nil
+	}
+
+	count := 0.0
+	for _, item := range items {
+
+		matched, err := callPredicate("count", lambda, item)
+		if err != nil {
+			return nil, err
+		}
+
+		if matched {
+			count++
+		}
+	}
+	return count, nil
+}
+
+/*
+	Returns the numeric sum of all elements of the array.
+	If a lambda is given, the results of the lambda for each element are summed instead.
+*/
+func sumFunction(arguments ...interface{}) (interface{}, error) {
+
+	var value interface{}
+	var err error
+
+	items, lambda, err := readArrayArguments("sum", arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := 0.0
+	for _, item := range items {
+
+		value = castToFloat64(item)
+		if lambda != nil {
+			value, err = lambda.Call(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		number, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("Function 'sum' cannot add value '%v', it is not a number", value)
+		}
+		sum += number
+	}
+	return sum, nil
+}
+
+/*
+	Reads the arguments of a builtin that requires both an array and a lambda, like `any(items, x => x > 1)`.
+*/
+func readLambdaArguments(name string, arguments []interface{}) ([]interface{}, *ExpressionLambda, error) {
+
+	items, lambda, err := readArrayArguments(name, arguments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if lambda == nil {
+		return nil, nil, fmt.Errorf("Function '%s' expects an array and a lambda, like '%s(items, x => x)'", name, name)
+	}
+	return items, lambda, nil
+}
+
+/*
+	Reads the arguments of a builtin that takes an array, and optionally a lambda as its last argument.
+	Function arguments which are themselves an `[]interface{}` are spread into individual arguments before the function is called,
+	so when no lambda is given, every argument is considered an element of the array (so `sum(1, 2, 3)` and `sum(items)` are equivalent).
+	A single argument of any other slice type is used as the array.
+*/
+func readArrayArguments(name string, arguments []interface{}) ([]interface{}, *ExpressionLambda, error) {
+
+	length := len(arguments)
+
+	if length > 0 {
+		if lambda, ok := arguments[length-1].(ExpressionLambda); ok {
+
+			if length != 2 {
+				return nil, nil, fmt.Errorf("Function '%s' expects an array and a lambda, got %d arguments", name, length)
+			}
+
+			items, ok := toArray(arguments[0])
+			if !ok {
+				return nil, nil, fmt.Errorf("Function '%s' expects an array as its first argument, got '%v'", name, arguments[0])
+			}
+			return items, &lambda, nil
+		}
+	}
+
+	if length == 1 {
+		if items, ok := toArray(arguments[0]); ok {
+			return items, nil, nil
+		}
+	}
+
+	for _, argument := range arguments {
+		if _, ok := argument.(ExpressionLambda); ok {
+			return nil, nil, fmt.Errorf("Function '%s' expects a lambda only as its last argument", name)
+		}
+	}
+	return arguments, nil, nil
+}
+
+func callPredicate(name string, lambda *ExpressionLambda, item interface{}) (bool, error) {
+
+	result, err := lambda.Call(item)
+	if err != nil {
+		return false, err
+	}
+
+	matched, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("Lambda passed to '%s' returned '%v', which is not a bool", name, result)
+	}
+	return matched, nil
+}
+
+/*
+	Converts any slice or array to an `[]interface{}`, since that's the only array type this library operates on.
+	Returns false if the given [value] is not a slice or array.
+*/
+func toArray(value interface{}) ([]interface{}, bool) {
+
+	if items, ok := value.([]interface{}); ok {
+		return items, true
+	}
+
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Slice, reflect.Array:
+	default:
+		return nil, false
+	}
+
+	ret := make([]interface{}, reflected.Len())
+	for i := range ret {
+		ret[i] = reflected.Index(i).Interface()
+	}
+	return ret, true
+}
@@ -0,0 +1,46 @@
+package govaluate
+
+// cachedParameters is a wrapper for Parameters which also keeps the results of shared stages,
+// so that each is only evaluated once during a single evaluation (or a single evaluation of a RuleSet).
+type cachedParameters struct {
+	Parameters
+	results []cachedResult
+
+	// set whenever an accessor calls a method, whose result can't be kept.
+	calledMethod bool
+}
+
+type cachedResult struct {
+	evaluated bool
+	value     interface{}
+	err       error
+}
+
+func newCachedParameters(parameters Parameters, slots int) *cachedParameters {
+	return &cachedParameters{
+		Parameters: parameters,
+		results:    make([]cachedResult, slots),
+	}
+}
+
+func (p *cachedParameters) evaluate(expr *EvaluableExpression, stage *evaluationStage) (interface{}, error) {
+
+	result := &p.results[stage.cacheSlot-1]
+
+	if result.evaluated {
+		return result.value, result.err
+	}
+
+	calledMethod := p.calledMethod
+	p.calledMethod = false
+
+	value, err := expr.computeStage(stage, p, true)
+
+	if !p.calledMethod {
+		result.value, result.err = value, err
+		result.evaluated = true
+	}
+
+	p.calledMethod = p.calledMethod || calledMethod
+	return value, err
+}
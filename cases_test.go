@@ -0,0 +1,90 @@
+package govaluate
+
+import (
+	"testing"
+)
+
+func TestGenerateCases(test *testing.T) {
+
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			input: "age >= 18 && country == 'NL'",
+			expected: []string{
+				"age >= 18 is true", "age >= 18 is false",
+				"country == 'NL' is true", "country == 'NL' is false",
+				"age >= 18 with age = 17", "age >= 18 with age = 18", "age >= 18 with age = 19",
+			},
+		},
+		{
+			input: "vip || total > 100 ? total * 0.9 : total",
+			expected: []string{
+				"vip is true", "vip is false",
+				"total > 100 is true", "total > 100 is false",
+				"vip || total > 100 is true", "vip || total > 100 is false",
+				"total > 100 with total = 99", "total > 100 with total = 100", "total > 100 with total = 101",
+			},
+		},
+		{
+			input: "(nickname ?? name) == 'bob'",
+			expected: []string{
+				"(nickname ?? name) == 'bob' is true", "(nickname ?? name) == 'bob' is false",
+				"nickname is null", "nickname is not null",
+			},
+		},
+		{
+			input: "x > 5 && x < 3",
+			expected: []string{
+				"x > 5 is true", "x > 5 is false", "x < 3 is false",
+				"x > 5 with x = 4", "x > 5 with x = 5", "x > 5 with x = 6",
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpression(testCase.input)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		generated, err := expression.GenerateCases()
+		if err != nil {
+			test.Logf("Failed to generate cases for '%s': %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		covered := make(map[string]bool)
+
+		for _, generatedCase := range generated {
+
+			// each case should have the result that it was generated with.
+			result, err := expression.Evaluate(generatedCase.Parameters)
+			if err == nil && result != generatedCase.Result {
+				test.Logf("Expected '%s' to be %v for %v, got %v", testCase.input, generatedCase.Result, generatedCase.Parameters, result)
+				test.Fail()
+			}
+
+			for _, branch := range generatedCase.Covers {
+				covered[branch] = true
+			}
+		}
+
+		for _, branch := range testCase.expected {
+			if !covered[branch] {
+				test.Logf("Expected cases of '%s' to cover '%s', got %v", testCase.input, branch, generated)
+				test.Fail()
+			}
+		}
+
+		if len(covered) != len(testCase.expected) {
+			test.Logf("Expected cases of '%s' to cover %d branches, got %v", testCase.input, len(testCase.expected), generated)
+			test.Fail()
+		}
+	}
+}
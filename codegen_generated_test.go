@@ -0,0 +1,168 @@
+// Code generated by govaluate. DO NOT EDIT.
+
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+)
+
+var (
+	generatedLiteralPatternPattern0 = regexp.MustCompile("^b.b$")
+	generatedLiteralPatternPattern1 = regexp.MustCompile("U")
+)
+
+type generatedInput struct {
+	Age     int
+	Country string
+	Name    string
+	Pattern string
+	Score   float64
+	VIP     bool
+}
+
+// generatedAdult evaluates: age >= 18 && country in ('US', 'NL')
+func generatedAdult(p *generatedInput) (bool, error) {
+	v1 := (float64(p.Age) >= 18.0)
+	if v1 {
+		v2 := p.Country
+		v1 = (v2 == "US" || v2 == "NL")
+	}
+	return v1, nil
+}
+
+// generatedHighScore evaluates: score * 2 + age > 100 || vip
+func generatedHighScore(p *generatedInput) (bool, error) {
+	v3 := (((p.Score * 2.0) + float64(p.Age)) > 100.0)
+	if !v3 {
+		v3 = p.VIP
+	}
+	return v3, nil
+}
+
+// generatedConcatenation evaluates: name + age == 'bob30' || country + vip == 'UStrue'
+func generatedConcatenation(p *generatedInput) (bool, error) {
+	v4 := (fmt.Sprintf("%v%v", p.Name, float64(p.Age)) == "bob30")
+	if !v4 {
+		v4 = (fmt.Sprintf("%v%v", p.Country, p.VIP) == "UStrue")
+	}
+	return v4, nil
+}
+
+// generatedLiteralPattern evaluates: name =~ '^b.b$' && !(country !~ 'U')
+func generatedLiteralPattern(p *generatedInput) (bool, error) {
+	v5 := generatedLiteralPatternPattern0.MatchString(p.Name)
+	if v5 {
+		v5 = generatedLiteralPatternPattern1.MatchString(p.Country)
+	}
+	return v5, nil
+}
+
+// generatedDynamicPattern evaluates: name =~ pattern
+func generatedDynamicPattern(p *generatedInput) (bool, error) {
+	v6, v7 := regexp.Compile(p.Pattern)
+	if v7 != nil {
+		return false, fmt.Errorf("Unable to compile regexp pattern '%v': %v", p.Pattern, v7)
+	}
+	return v6.MatchString(p.Name), nil
+}
+
+// generatedTernary evaluates: vip ? score > 10 : age % 2 == 0
+func generatedTernary(p *generatedInput) (bool, error) {
+	var v8 bool
+	if p.VIP {
+		v8 = (p.Score > 10.0)
+	} else {
+		v8 = (math.Mod(float64(p.Age), 2.0) == 0.0)
+	}
+	return v8, nil
+}
+
+// generatedBitwise evaluates: (age & 3) << 1 == 2 || ~age < -5 || age >> 1 | 1 == 3
+func generatedBitwise(p *generatedInput) (bool, error) {
+	v9 := (float64(uint64(float64(int64(float64(p.Age))&int64(3)))<<uint64(1)) == 2.0)
+	if !v9 {
+		v9 = (float64(^int64(float64(p.Age))) < (-5.0))
+	}
+	v10 := v9
+	if !v10 {
+		v10 = (float64(int64(float64(uint64(float64(p.Age))>>uint64(1)))|int64(1)) == 3.0)
+	}
+	return v10, nil
+}
+
+// generatedArithmetic evaluates: score ** 2 / 3 - age >= 1 && score / 0 > 0
+func generatedArithmetic(p *generatedInput) (bool, error) {
+	v11 := (((math.Pow(p.Score, 2.0) / 3.0) - float64(p.Age)) >= 1.0)
+	if v11 {
+		v11 = ((p.Score * math.Inf(1)) > 0.0)
+	}
+	return v11, nil
+}
+
+// generatedBinding evaluates: let total = score * age; total > 50 && total < 1000
+func generatedBinding(p *generatedInput) (bool, error) {
+	v12 := (p.Score * float64(p.Age))
+	v13 := (v12 > 50.0)
+	if v13 {
+		v13 = (v12 < 1000.0)
+	}
+	return v13, nil
+}
+
+// generatedTypeError evaluates: vip && name > 5
+func generatedTypeError(p *generatedInput) (bool, error) {
+	v14 := p.VIP
+	if v14 {
+		return false, fmt.Errorf("Value '%v' cannot be used with the comparator '%v', it is not a number", p.Name, ">")
+	}
+	return v14, nil
+}
+
+// generatedAlwaysFails evaluates: name - 1 > 0 || true
+func generatedAlwaysFails(p *generatedInput) (bool, error) {
+	return false, fmt.Errorf("Value '%v' cannot be used with the modifier '%v', it is not a number", p.Name, "-")
+}
+
+// generatedCoalesce evaluates: (vip ?? false) || country == null || country != 'DE'
+func generatedCoalesce(p *generatedInput) (bool, error) {
+	v15 := p.VIP
+	if !v15 {
+		v15 = false
+	}
+	v16 := v15
+	if !v16 {
+		v16 = (p.Country != "DE")
+	}
+	return v16, nil
+}
+
+// generatedStrings evaluates: name < country || name >= 'b'
+func generatedStrings(p *generatedInput) (bool, error) {
+	v17 := (p.Name < p.Country)
+	if !v17 {
+		v17 = (p.Name >= "b")
+	}
+	return v17, nil
+}
+
+// generatedList evaluates: age in (18, 21, 'x') || name in ('bob', null)
+func generatedList(p *generatedInput) (bool, error) {
+	v18 := float64(p.Age)
+	v19 := (v18 == 18.0 || v18 == 21.0)
+	if !v19 {
+		v20 := p.Name
+		v19 = (v20 == "bob")
+	}
+	return v19, nil
+}
+
+// generatedNullConcatenation evaluates: vip || name + null == 'bob'
+func generatedNullConcatenation(p *generatedInput) (bool, error) {
+	v21 := p.VIP
+	if !v21 {
+		return false, fmt.Errorf("Value '%v' cannot be used with the modifier '%v', it is not a number", p.Name, "+")
+	}
+	return v21, nil
+}
@@ -0,0 +1,190 @@
+package govaluate
+
+import (
+	"flag"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+var updateGenerated = flag.Bool("update", false, "rewrite the generated Go which the tests compare the interpreter to")
+
+var generatedOptions = GoOptions{
+	Package:      "govaluate",
+	Input:        "generatedInput",
+	DeclareInput: true,
+	Fields: map[string]GoField{
+		"age":     {Name: "Age", Type: "int"},
+		"score":   {Name: "Score", Type: "float64"},
+		"name":    {Name: "Name", Type: "string"},
+		"country": {Name: "Country", Type: "string"},
+		"vip":     {Name: "VIP", Type: "bool"},
+		"pattern": {Name: "Pattern", Type: "string"},
+	},
+}
+
+var generatedCases = []struct {
+	name     string
+	input    string
+	function func(*generatedInput) (bool, error)
+}{
+	{"generatedAdult", "age >= 18 && country in ('US', 'NL')", generatedAdult},
+	{"generatedHighScore", "score * 2 + age > 100 || vip", generatedHighScore},
+	{"generatedConcatenation", "name + age == 'bob30' || country + vip == 'UStrue'", generatedConcatenation},
+	{"generatedLiteralPattern", "name =~ '^b.b$' && !(country !~ 'U')", generatedLiteralPattern},
+	{"generatedDynamicPattern", "name =~ pattern", generatedDynamicPattern},
+	{"generatedTernary", "vip ? score > 10 : age % 2 == 0", generatedTernary},
+	{"generatedBitwise", "(age & 3) << 1 == 2 || ~age < -5 || age >> 1 | 1 == 3", generatedBitwise},
+	{"generatedArithmetic", "score ** 2 / 3 - age >= 1 && score / 0 > 0", generatedArithmetic},
+	{"generatedBinding", "let total = score * age; total > 50 && total < 1000", generatedBinding},
+	{"generatedTypeError", "vip && name > 5", generatedTypeError},
+	{"generatedAlwaysFails", "name - 1 > 0 || true", generatedAlwaysFails},
+	{"generatedCoalesce", "(vip ?? false) || country == null || country != 'DE'", generatedCoalesce},
+	{"generatedStrings", "name < country || name >= 'b'", generatedStrings},
+	{"generatedList", "age in (18, 21, 'x') || name in ('bob', null)", generatedList},
+	{"generatedNullConcatenation", "vip || name + null == 'bob'", generatedNullConcatenation},
+}
+
+/*
+	Checks that the Go generated for each case is what the tests were compiled with.
+	Run with `-update` to rewrite it.
+*/
+func TestGenerateGo(test *testing.T) {
+
+	var functions []GoFunction
+
+	for _, testCase := range generatedCases {
+
+		expression, err := NewEvaluableExpression(testCase.input)
+		if err != nil {
+			test.Fatalf("Test '%s' failed to parse: %v", testCase.input, err)
+		}
+		functions = append(functions, GoFunction{Name: testCase.name, Expression: expression})
+	}
+
+	generated, err := GenerateGo(generatedOptions, functions...)
+	if err != nil {
+		test.Fatalf("Failed to generate Go: %v", err)
+	}
+
+	if *updateGenerated {
+		err = ioutil.WriteFile("codegen_generated_test.go", generated, 0644)
+		if err != nil {
+			test.Fatalf("Failed to write generated Go: %v", err)
+		}
+	}
+
+	existing, err := ioutil.ReadFile("codegen_generated_test.go")
+	if err != nil {
+		test.Fatalf("Failed to read generated Go: %v", err)
+	}
+
+	if string(existing) != string(generated) {
+		test.Logf("The generated Go differs from codegen_generated_test.go; run the tests with -update to rewrite it")
+		test.Fail()
+	}
+}
+
+/*
+	Checks that the generated Go evaluates to the same results, and fails with the same errors, as the interpreter.
+*/
+func TestGeneratedGo(test *testing.T) {
+
+	random := rand.New(rand.NewSource(1))
+
+	names := []string{"bob", "bab", "alice", "", "bob30"}
+	countries := []string{"US", "NL", "DE", ""}
+	patterns := []string{"^b", "a+", "(", "[z"}
+	scores := []float64{0, -1.5, 12.25, 100, math.Inf(1)}
+
+	for _, testCase := range generatedCases {
+
+		expression, err := NewEvaluableExpression(testCase.input)
+		if err != nil {
+			test.Fatalf("Test '%s' failed to parse: %v", testCase.input, err)
+		}
+
+		for i := 0; i < 500; i++ {
+
+			input := &generatedInput{
+				Age:     random.Intn(120) - 10,
+				Score:   scores[random.Intn(len(scores))],
+				Name:    names[random.Intn(len(names))],
+				Country: countries[random.Intn(len(countries))],
+				VIP:     random.Intn(2) == 0,
+				Pattern: patterns[random.Intn(len(patterns))],
+			}
+
+			if random.Intn(2) == 0 {
+				input.Score = random.Float64()*200 - 50
+			}
+
+			expected, expectedErr := expression.Evaluate(map[string]interface{}{
+				"age":     input.Age,
+				"score":   input.Score,
+				"name":    input.Name,
+				"country": input.Country,
+				"vip":     input.VIP,
+				"pattern": input.Pattern,
+			})
+
+			actual, actualErr := testCase.function(input)
+
+			if expectedErr != nil || actualErr != nil {
+				if expectedErr == nil || actualErr == nil || expectedErr.Error() != actualErr.Error() {
+					test.Logf("Test '%s' failed with %+v", testCase.input, *input)
+					test.Logf("Expected error %v, actual %v", expectedErr, actualErr)
+					test.Fail()
+					break
+				}
+				continue
+			}
+
+			if expected != actual {
+				test.Logf("Test '%s' failed with %+v", testCase.input, *input)
+				test.Logf("Expected %v, actual %v", expected, actual)
+				test.Fail()
+				break
+			}
+		}
+	}
+}
+
+func TestGenerateGoFailure(test *testing.T) {
+
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "f(age) > 1", expected: "Cannot generate Go for 'f(age)'"},
+		{input: "missing > 1", expected: "Cannot generate Go for parameter 'missing', since it isn't one of the fields"},
+		{input: "age + 1", expected: "Cannot generate Go for 'age + 1', since it doesn't evaluate to a bool"},
+		{input: "(vip ? 1 : 'a') == 1", expected: "Cannot generate Go for 'vip ? 1 : 'a'', since its branches may be of different types"},
+		{input: "(vip ? null : country) == 'US'", expected: "Cannot generate Go for 'vip ? null : country', since its branches may be of different types"},
+		{input: "(vip ? age) == 1", expected: "Cannot generate Go for 'vip ? age'"},
+	}
+
+	functions := map[string]ExpressionFunction{
+		"f": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0], nil
+		},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpressionWithFunctions(testCase.input, functions)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		_, err = expression.GenerateGo("generated", generatedOptions)
+		if err == nil || err.Error() != testCase.expected {
+			test.Logf("Test '%s' failed", testCase.input)
+			test.Logf("Expected error '%s', actual %v", testCase.expected, err)
+			test.Fail()
+		}
+	}
+}
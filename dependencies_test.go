@@ -0,0 +1,131 @@
+package govaluate
+
+import (
+	"reflect"
+	"testing"
+)
+
+/*
+	Represents a test of finding the dependencies of an expression.
+*/
+type DependenciesTest struct {
+	Name     string
+	Input    string
+	Expected Dependencies
+}
+
+func TestDependencies(test *testing.T) {
+
+	testCases := []DependenciesTest{
+		{
+			Name:     "No dependencies",
+			Input:    "1 + 2 > 2",
+			Expected: Dependencies{},
+		},
+		{
+			Name:  "Repeated parameters",
+			Input: "foo > 1 && foo < bar + foo",
+			Expected: Dependencies{
+				Parameters: []ParameterDependency{
+					{Name: "bar", Conditional: true},
+					{Name: "foo"},
+				},
+			},
+		},
+		{
+			Name:  "Accessors",
+			Input: "user.Address.City == 'Amsterdam' && user.Age > 18 || user.Address?.City == ''",
+			Expected: Dependencies{
+				Parameters: []ParameterDependency{
+					{Name: "user", Accessors: []string{"user.Address.City", "user.Age"}},
+				},
+			},
+		},
+		{
+			Name:  "Method arguments",
+			Input: "foo.Dunk(bar) == 'xdunk'",
+			Expected: Dependencies{
+				Parameters: []ParameterDependency{
+					{Name: "bar"},
+					{Name: "foo", Accessors: []string{"foo.Dunk"}},
+				},
+			},
+		},
+		{
+			Name:  "Short-circuited branches",
+			Input: "a ? b : c ?? d",
+			Expected: Dependencies{
+				Parameters: []ParameterDependency{
+					{Name: "a"},
+					{Name: "b", Conditional: true},
+					{Name: "c", Conditional: true},
+					{Name: "d", Conditional: true},
+				},
+			},
+		},
+		{
+			Name:  "Used both conditionally and not",
+			Input: "(a || b) == b",
+			Expected: Dependencies{
+				Parameters: []ParameterDependency{
+					{Name: "a"},
+					{Name: "b"},
+				},
+			},
+		},
+		{
+			Name:  "Functions",
+			Input: "any(users, u => u.Age > limit) && len(name) > 0 && len(other) > 0",
+			Expected: Dependencies{
+				Parameters: []ParameterDependency{
+					{Name: "limit", Conditional: true},
+					{Name: "name", Conditional: true},
+					{Name: "other", Conditional: true},
+					{Name: "users"},
+				},
+				Functions: []string{"any", "len"},
+			},
+		},
+		{
+			Name:  "Let bindings",
+			Input: "let total = price * amount; total > 100 && total < max",
+			Expected: Dependencies{
+				Parameters: []ParameterDependency{
+					{Name: "amount"},
+					{Name: "max", Conditional: true},
+					{Name: "price"},
+				},
+			},
+		},
+		{
+			Name:  "Escaped parameters",
+			Input: "[foo.bar] > 1",
+			Expected: Dependencies{
+				Parameters: []ParameterDependency{
+					{Name: "foo.bar"},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		expression, err := NewEvaluableExpressionWithFunctions(testCase.Input, map[string]ExpressionFunction{
+			"len": func(arguments ...interface{}) (interface{}, error) {
+				return float64(len(arguments[0].(string))), nil
+			},
+		})
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %s", testCase.Name, err)
+			test.Fail()
+			continue
+		}
+
+		actual := expression.Dependencies()
+		if !reflect.DeepEqual(actual, testCase.Expected) {
+			test.Logf("Test '%s' failed", testCase.Name)
+			test.Logf("Expected %+v, got %+v", testCase.Expected, actual)
+			test.Fail()
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package govaluate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDerivative(test *testing.T) {
+
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "x", expected: "1"},
+		{input: "y + 1", expected: "0"},
+		{input: "x * x", expected: "x + x"},
+		{input: "3 * x ** 2 + 2 * x + 1", expected: "6 * x + 2"},
+		{input: "y * x", expected: "y"},
+		{input: "x * y - x", expected: "y - 1"},
+		{input: "x / y", expected: "1 / y"},
+		{input: "1 / x", expected: "-1 / x ** 2"},
+		{input: "x / (x + 1)", expected: "((x + 1) - x) / (x + 1) ** 2"},
+		{input: "-x ** 3", expected: "-3 * -x ** 2"},
+		{input: "(2 * x + y) ** 3", expected: "6 * (2 * x + y) ** 2"},
+		{input: "y % 2 * x", expected: "y % 2"},
+		{input: "x > 0 ? x : -x", expected: "x > 0 ? 1 : -1"},
+		{input: "x > 0 ? x * y : 0", expected: "x > 0 ? y : 0"},
+		{input: "y > 5 ? 5 : x", expected: "y > 5 ? 0 : 1"},
+		{input: "x > 1 ? 5 : x * y", expected: "x > 1 ? 0 : y"},
+		{input: "let z = x * 2; z * z", expected: "2 * (x * 2) + 2 * (x * 2)"},
+		{input: "sin(x ** 2)", expected: "cos(x ** 2) * (2 * x)"},
+		{input: "sin(y) * x", expected: "sin(y)"},
+		{input: "2 * sin(x) + cos(x)", expected: "2 * cos(x) + -sin(x)"},
+		{input: "2 ** x", expected: "0.6931471805599453 * 2 ** x"},
+		{input: "10 ** (2 * x) * y", expected: "4.605170185988092 * 10 ** (2 * x) * y"},
+		{input: "1 ** x", expected: "0"},
+	}
+
+	env := NewEnv()
+	env.Functions["sin"] = func(arguments ...interface{}) (interface{}, error) {
+		return math.Sin(arguments[0].(float64)), nil
+	}
+	env.Functions["cos"] = func(arguments ...interface{}) (interface{}, error) {
+		return math.Cos(arguments[0].(float64)), nil
+	}
+	env.Derivatives["sin"] = "cos(x)"
+	env.Derivatives["cos"] = "-sin(x)"
+
+	for _, testCase := range cases {
+
+		expression, err := env.Compile(testCase.input)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		derivative, err := env.Derivative(expression, "x")
+		if err != nil {
+			test.Logf("Test '%s' failed: %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		if derivative.String() != testCase.expected {
+			test.Logf("Test '%s' failed", testCase.input)
+			test.Logf("Expected derivative '%s', actual '%s'", testCase.expected, derivative.String())
+			test.Fail()
+		}
+
+		// the derivative should agree with how fast the expression changes.
+		for _, x := range []float64{-1.5, 0.5, 2} {
+
+			parameters := map[string]interface{}{"x": x, "y": 3.0}
+			actual, err := derivative.Evaluate(parameters)
+			if err != nil {
+				test.Logf("Test '%s' failed to evaluate: %v", testCase.input, err)
+				test.Fail()
+				break
+			}
+
+			step := 1e-6
+			parameters["x"] = x + step
+			above, _ := expression.Evaluate(parameters)
+			parameters["x"] = x - step
+			below, _ := expression.Evaluate(parameters)
+
+			approximate := (above.(float64) - below.(float64)) / (2 * step)
+			if math.Abs(approximate-actual.(float64)) > 1e-3*math.Max(1, math.Abs(approximate)) {
+				test.Logf("Test '%s' failed at x = %v", testCase.input, x)
+				test.Logf("Expected about %v, actual %v", approximate, actual)
+				test.Fail()
+			}
+		}
+	}
+}
+
+func TestDerivativeFailure(test *testing.T) {
+
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "x % 2", expected: "Cannot differentiate 'x % 2', since '%' isn't differentiable"},
+		{input: "x & 1", expected: "Cannot differentiate 'x & 1', since '&' isn't differentiable"},
+		{input: "x ** x", expected: "Cannot differentiate 'x ** x' with respect to 'x', since both its base and exponent depend on it"},
+		{input: "y ** x", expected: "Cannot differentiate 'y ** x' with respect to 'x', since its exponent depends on it, and its base isn't a positive number"},
+		{input: "(-2) ** x", expected: "Cannot differentiate '-2 ** x' with respect to 'x', since its exponent depends on it, and its base isn't a positive number"},
+		{input: "f(x)", expected: "No derivative is known for function 'f'"},
+		{input: "f(x, 1)", expected: "Cannot differentiate 'f(x, 1)', since only functions of one argument can be differentiated"},
+		{input: "x ?? 1", expected: "Cannot differentiate 'x ?? 1', since '??' isn't differentiable"},
+	}
+
+	functions := map[string]ExpressionFunction{
+		"f": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0], nil
+		},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpressionWithFunctions(testCase.input, functions)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		_, err = Derivative(expression, "x")
+		if err == nil || err.Error() != testCase.expected {
+			test.Logf("Test '%s' failed", testCase.input)
+			test.Logf("Expected error '%s', actual %v", testCase.expected, err)
+			test.Fail()
+		}
+	}
+}
@@ -14,6 +14,7 @@ type dummyParameter struct {
 	BoolFalse bool
 	Nil       interface{}
 	Nested    dummyNestedParameter
+	NestedPtr *dummyNestedParameter
 }
 
 func (dummyParameter) Func() string {
@@ -51,6 +52,18 @@ func (dummyParameter) AlwaysFail() (interface{}, error) {
 	return nil, errors.New("function should always fail")
 }
 
+/*
+	Struct whose method returns something different every time it's called.
+*/
+type dummyCounter struct {
+	Count int
+}
+
+func (counter *dummyCounter) Next() float64 {
+	counter.Count++
+	return float64(counter.Count)
+}
+
 type dummyNestedParameter struct {
 	Funk string
 }
@@ -69,6 +82,15 @@ var dummyParameterInstance = dummyParameter{
 	},
 }
 
+var itemsParameter = EvaluationParameter{
+	Name: "items",
+	Value: []dummyNestedParameter{
+		{Funk: "first"},
+		{Funk: "second"},
+		{Funk: "third"},
+	},
+}
+
 var fooParameter = EvaluationParameter{
 	Name:  "foo",
 	Value: dummyParameterInstance,
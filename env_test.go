@@ -0,0 +1,188 @@
+package govaluate
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvCompile(test *testing.T) {
+
+	env := NewEnv()
+	env.Functions["double"] = func(arguments ...interface{}) (interface{}, error) {
+		return arguments[0].(float64) * 2, nil
+	}
+	env.ChecksTypes = false
+	env.QueryDateFormat = "2006"
+
+	expression, err := env.Compile("double(foo) > 10")
+	if err != nil {
+		test.Logf("Failed to compile: %v", err)
+		test.FailNow()
+	}
+
+	if expression.ChecksTypes || expression.QueryDateFormat != "2006" {
+		test.Logf("Options were not applied: %+v", expression)
+		test.Fail()
+	}
+
+	result, err := expression.Evaluate(map[string]interface{}{"foo": 6})
+	if err != nil || result != true {
+		test.Logf("Compiled expression gave '%v', %v", result, err)
+		test.Fail()
+	}
+
+	if expression.String() != "double(foo) > 10" {
+		test.Logf("Compiled expression lost its text: '%s'", expression.String())
+		test.Fail()
+	}
+}
+
+func TestEnvDates(test *testing.T) {
+
+	env := NewEnv()
+	env.ParseDates = false
+
+	expression, _ := env.Compile("'2014-01-02'")
+	if expression.Tokens()[0].Kind != STRING {
+		test.Logf("Expected date to be a string when dates aren't parsed, got %v", expression.Tokens()[0].Kind)
+		test.Fail()
+	}
+
+	env = NewEnv()
+	env.DateFormats = []string{"02/01/2006"}
+	env.DateLocation = time.UTC
+
+	expression, _ = env.Compile("'02/01/2014'")
+	token := expression.Tokens()[0]
+
+	if token.Kind != TIME || !token.Value.(time.Time).Equal(time.Date(2014, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		test.Logf("Expected custom date format in UTC, got %v '%v'", token.Kind, token.Value)
+		test.Fail()
+	}
+
+	expression, _ = env.Compile("'2014-01-02'")
+	if expression.Tokens()[0].Kind != STRING {
+		test.Logf("Expected date not in the given formats to be a string, got %v", expression.Tokens()[0].Kind)
+		test.Fail()
+	}
+}
+
+func TestEnvLimits(test *testing.T) {
+
+	length := NewEnv()
+	length.MaxLength = 10
+
+	tokens := NewEnv()
+	tokens.MaxTokens = 5
+
+	depth := NewEnv()
+	depth.MaxDepth = 2
+
+	cases := []struct {
+		env      *Env
+		input    string
+		expected string
+	}{
+		{length, "1 + 2 + 3 + 4", "longer than the limit of 10 characters"},
+		{length, "1 + 2 + 34", ""},
+		{length, "'éééééééé'", ""},
+		{length, "'ééééééééé'", "longer than the limit of 10 characters"},
+		{tokens, "1 + 2 + 3", ""},
+		{tokens, "1 + 2 + 3 + 4", "7 tokens, more than the limit of 5"},
+		{depth, "((1)) + ((2))", ""},
+		{depth, "(((1)))", "deeper than the limit of 2"},
+	}
+
+	for _, testCase := range cases {
+
+		_, err := testCase.env.Compile(testCase.input)
+
+		if testCase.expected == "" {
+			if err != nil {
+				test.Logf("Expected '%s' to be within limits, got %v", testCase.input, err)
+				test.Fail()
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), testCase.expected) {
+			test.Logf("Expected '%s' to fail with '%s', got %v", testCase.input, testCase.expected, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestEnvConstants(test *testing.T) {
+
+	env := NewEnv()
+	env.Constants["MAX_RETRIES"] = 3
+	env.Constants["PREMIUM_TIER"] = "premium"
+	env.Constants["ENABLED"] = true
+	env.Constants["NOTHING"] = nil
+	env.Constants["LAUNCH"] = time.Date(2014, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	evaluationTests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"retries < MAX_RETRIES * 2 && tier == PREMIUM_TIER", true},
+		{"[MAX_RETRIES] + 1", 4.0},
+		{"ENABLED ? NOTHING ?? 'none' : 'disabled'", "none"},
+		{"'2015-01-01' > LAUNCH", true},
+		{"count((1, 2, 3, 4), x => x > MAX_RETRIES)", 1.0},
+	}
+
+	for _, evaluationTest := range evaluationTests {
+
+		expression, err := env.Compile(evaluationTest.input)
+		if err != nil {
+			test.Logf("Failed to compile '%s': %v", evaluationTest.input, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.Evaluate(map[string]interface{}{"retries": 1, "tier": "premium"})
+		if err != nil || result != evaluationTest.expected {
+			test.Logf("'%s' gave '%v' (%v), expected '%v'", evaluationTest.input, result, err, evaluationTest.expected)
+			test.Fail()
+		}
+	}
+
+	// operations on constants alone are done at compile time
+	expression, _ := env.Compile("(MAX_RETRIES * 2 + 1) * retries")
+
+	if expression.evaluationStages.leftStage.symbol != LITERAL {
+		test.Logf("Expected operations on constants to be folded into a literal, got %v", expression.evaluationStages.leftStage.symbol)
+		test.Fail()
+	}
+
+	vars := expression.Vars()
+	if len(vars) != 1 || vars[0] != "retries" {
+		test.Logf("Expected constants to be excluded from Vars(), got %v", vars)
+		test.Fail()
+	}
+}
+
+func TestEnvConstantFailures(test *testing.T) {
+
+	env := NewEnv()
+	env.Constants["LIMIT"] = 10
+	env.Constants["ALLOWED"] = []string{"a", "b"}
+
+	cases := map[string]string{
+		"x in ALLOWED":               "Constant 'ALLOWED' is a []string",
+		"let LIMIT = 5; 1":           "Unable to bind 'LIMIT', it is a constant",
+		"LIMIT => LIMIT + 1":         "Unable to use 'LIMIT' as a lambda parameter, it is a constant",
+		"count((1, 2), LIMIT=>true)": "Unable to use 'LIMIT' as a lambda parameter, it is a constant",
+	}
+
+	for input, expected := range cases {
+
+		_, err := env.Compile(input)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			test.Logf("Expected '%s' to fail with '%s', got %v", input, expected, err)
+			test.Fail()
+		}
+	}
+}
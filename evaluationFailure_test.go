@@ -114,6 +114,18 @@ func TestModifierTyping(test *testing.T) {
 			Input:    "number + bool",
 			Expected: INVALID_MODIFIER_TYPES,
 		},
+		{
+
+			Name:     "PLUS literal string to null",
+			Input:    "'x' + null",
+			Expected: INVALID_MODIFIER_TYPES,
+		},
+		{
+
+			Name:     "PLUS null to string",
+			Input:    "(false ? 1) + string",
+			Expected: INVALID_MODIFIER_TYPES,
+		},
 		{
 
 			Name:     "MINUS number to bool",
@@ -496,6 +508,63 @@ func TestInvalidParameterCalls(test *testing.T) {
 	runEvaluationFailureTests(evaluationTests, test)
 }
 
+func TestNullFailures(test *testing.T) {
+
+	evaluationTests := []EvaluationFailureTest{
+		{
+			Name:       "Accessor on nil field",
+			Input:      "foo.NestedPtr.Funk",
+			Parameters: fooFailureParameters,
+			Expected:   "'NestedPtr' is nil",
+		},
+		{
+			Name:     "Null in arithmetic",
+			Input:    "null + 1",
+			Expected: INVALID_MODIFIER_TYPES,
+		},
+		{
+			Name:     "Null in comparison",
+			Input:    "null > 1",
+			Expected: INVALID_COMPARATOR_TYPES,
+		},
+	}
+
+	runEvaluationFailureTests(evaluationTests, test)
+}
+
+func TestLambdaFailures(test *testing.T) {
+
+	evaluationTests := []EvaluationFailureTest{
+		{
+			Name:     "Predicate lambda returning a number",
+			Input:    "any((1, 2), x => x + 1)",
+			Expected: "which is not a bool",
+		},
+		{
+			Name:     "Higher-order function without a lambda",
+			Input:    "any((1, 2))",
+			Expected: "expects an array and a lambda",
+		},
+		{
+			Name:     "Higher-order function over a non-array",
+			Input:    "filter(number, x => true)",
+			Expected: "expects an array",
+		},
+		{
+			Name:     "Sum of non-numeric values",
+			Input:    "sum(('a', 'b'))",
+			Expected: "it is not a number",
+		},
+		{
+			Name:     "Lambda body with type error",
+			Input:    "any((1, 2), x => x && true)",
+			Expected: INVALID_LOGICALOP_TYPES,
+		},
+	}
+
+	runEvaluationFailureTests(evaluationTests, test)
+}
+
 func runEvaluationFailureTests(evaluationTests []EvaluationFailureTest, test *testing.T) {
 
 	var expression *EvaluableExpression
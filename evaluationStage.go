@@ -39,6 +39,16 @@ type evaluationStage struct {
 
 	// regardless of which type check is used, this string format will be used as the error message for type errors
 	typeErrorFormat string
+
+	// the name this stage introduces into scope for its right stage, such as the parameter of a lambda.
+	binding string
+
+	// the parameter name or accessor path (such as "foo.Bar", or "foo.?Bar" for optional parts) which this stage reads,
+	// or the name of the function it calls (if known).
+	reference string
+
+	// if non-zero, this stage is shared with others that are identical to it, and its result is kept in this slot (plus one) of the evaluation's cache.
+	cacheSlot int
 }
 
 var (
@@ -46,6 +56,11 @@ var (
 	_false = interface{}(false)
 )
 
+// what the condition of a ternary with an else evaluates to when it's false; see `ternaryConditionStage`.
+type unmatchedTernary struct{}
+
+var ternaryUnmatched = interface{}(unmatchedTernary{})
+
 func (s *evaluationStage) swapWith(other *evaluationStage) {
 	temp := *other
 	other.setToNonStage(*s)
@@ -59,6 +74,8 @@ func (s *evaluationStage) setToNonStage(other evaluationStage) {
 	s.rightTypeCheck = other.rightTypeCheck
 	s.typeCheck = other.typeCheck
 	s.typeErrorFormat = other.typeErrorFormat
+	s.binding = other.binding
+	s.reference = other.reference
 }
 
 func (s *evaluationStage) isShortCircuitable() bool {
@@ -122,10 +139,10 @@ func ltStage(left, right interface{}, parameters Parameters) (interface{}, error
 	return boolIface(left.(float64) < right.(float64)), nil
 }
 func equalStage(left, right interface{}, parameters Parameters) (interface{}, error) {
-	return boolIface(reflect.DeepEqual(left, right)), nil
+	return boolIface(isEqual(left, right)), nil
 }
 func notEqualStage(left, right interface{}, parameters Parameters) (interface{}, error) {
-	return boolIface(!reflect.DeepEqual(left, right)), nil
+	return boolIface(!isEqual(left, right)), nil
 }
 func andStage(left, right interface{}, parameters Parameters) (interface{}, error) {
 	return boolIface(left.(bool) && right.(bool)), nil
@@ -148,6 +165,17 @@ func ternaryIfStage(left, right interface{}, parameters Parameters) (interface{}
 	}
 	return nil, nil
 }
+
+/*
+	The condition of a ternary which has an else, such as `a ? b` in `a ? b : c`. When `a` is false, this evaluates to
+	`ternaryUnmatched` rather than nil, so that the else can tell that apart from `b` being null.
+*/
+func ternaryConditionStage(left, right interface{}, parameters Parameters) (interface{}, error) {
+	if left.(bool) {
+		return right, nil
+	}
+	return ternaryUnmatched, nil
+}
 func ternaryElseStage(left, right interface{}, parameters Parameters) (interface{}, error) {
 	if left != nil {
 		return left, nil
@@ -155,6 +183,13 @@ func ternaryElseStage(left, right interface{}, parameters Parameters) (interface
 	return right, nil
 }
 
+func coalesceStage(left, right interface{}, parameters Parameters) (interface{}, error) {
+	if !isNil(left) {
+		return left, nil
+	}
+	return right, nil
+}
+
 func regexStage(left, right interface{}, parameters Parameters) (interface{}, error) {
 	var pattern *regexp.Regexp
 	var err error
@@ -271,8 +306,18 @@ func typeConvertParams(method reflect.Value, params []reflect.Value) ([]reflect.
 }
 
 //nolint: gocognit
-func makeAccessorStage(pair []string) evaluationOperator {
-	reconstructed := strings.Join(pair, ".")
+func makeAccessorStage(accessor []string) evaluationOperator {
+
+	// parts prefixed with '?' were accessed with `?.`, and evaluate to nil when the value before them is nil.
+	pair := make([]string, len(accessor))
+	optional := make([]bool, len(accessor))
+
+	for i, part := range accessor {
+		optional[i] = strings.HasPrefix(part, "?")
+		pair[i] = strings.TrimPrefix(part, "?")
+	}
+
+	reconstructed := strings.Replace(strings.Join(accessor, "."), ".?", optionalAccessor, -1)
 
 	return func(left interface{}, right interface{}, parameters Parameters) (ret interface{}, err error) {
 
@@ -296,6 +341,10 @@ func makeAccessorStage(pair []string) evaluationOperator {
 
 		for i := 1; i < len(pair); i++ {
 
+			if optional[i] && isNil(value) {
+				return nil, nil
+			}
+
 			coreValue := reflect.ValueOf(value)
 
 			var corePtrVal reflect.Value
@@ -306,6 +355,10 @@ func makeAccessorStage(pair []string) evaluationOperator {
 				coreValue = coreValue.Elem()
 			}
 
+			if isNil(value) {
+				return nil, errors.New("Unable to access '" + pair[i] + "', '" + pair[i-1] + "' is nil (use '" + optionalAccessor + "' to allow this)")
+			}
+
 			if coreValue.Kind() != reflect.Struct {
 				return nil, errors.New("Unable to access '" + pair[i] + "', '" + pair[i-1] + "' is not a struct")
 			}
@@ -348,6 +401,11 @@ func makeAccessorStage(pair []string) evaluationOperator {
 				return nil, errors.New("Method call failed - '" + pair[0] + "." + pair[1] + "': " + err.Error())
 			}
 
+			// methods may have side effects, so a result which depends on a call is never shared.
+			if cache, ok := parameters.(*cachedParameters); ok {
+				cache.calledMethod = true
+			}
+
 			returned := method.Call(params)
 			retLength := len(returned)
 
@@ -383,12 +441,16 @@ func makeAccessorStage(pair []string) evaluationOperator {
 }
 
 func separatorStage(left, right interface{}, parameters Parameters) (interface{}, error) {
-	switch left := left.(type) {
-	case []interface{}:
-		return append(left, right), nil
-	default:
-		return []interface{}{left, right}, nil
-	}
+	return []interface{}{left, right}, nil
+}
+func appendSeparatorStage(left, right interface{}, parameters Parameters) (interface{}, error) {
+
+	// the list on the left may be shared with other stages, so it's copied rather than appended to.
+	list := left.([]interface{})
+	ret := make([]interface{}, len(list), len(list)+1)
+	copy(ret, list)
+
+	return append(ret, right), nil
 }
 
 func inStage(left, right interface{}, parameters Parameters) (interface{}, error) {
@@ -435,6 +497,11 @@ func additionTypeCheck(left, right interface{}) bool {
 	if isFloat64(left) && isFloat64(right) {
 		return true
 	}
+
+	// null isn't concatenated, as "<nil>", to strings.
+	if left == nil || right == nil {
+		return false
+	}
 	return isString(left) || isString(right)
 }
 
@@ -449,6 +516,36 @@ func comparatorTypeCheck(left, right interface{}) bool {
 	return isString(left) && isString(right)
 }
 
+/*
+	Returns true if the given [value] is nil, or is a nil pointer (or map, slice, etc) hiding inside a non-nil interface.
+	Parameters and struct fields frequently hold the latter, which should still compare equal to `null`.
+*/
+func isNil(value interface{}) bool {
+
+	if value == nil {
+		return true
+	}
+
+	reflected := reflect.ValueOf(value)
+
+	switch reflected.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return reflected.IsNil()
+	}
+	return false
+}
+
+/*
+	Equality is deep equality, except that all kinds of nil are equal to one another.
+*/
+func isEqual(left, right interface{}) bool {
+
+	if isNil(left) || isNil(right) {
+		return isNil(left) && isNil(right)
+	}
+	return reflect.DeepEqual(left, right)
+}
+
 func isArray(value interface{}) bool {
 	_, ok := value.([]interface{})
 	return ok
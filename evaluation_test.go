@@ -3,7 +3,10 @@ package govaluate
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -1248,6 +1251,636 @@ func TestParameterizedEvaluation(test *testing.T) {
 	runEvaluationTests(evaluationTests, test)
 }
 
+func TestCommentEvaluation(test *testing.T) {
+
+	evaluationTests := []EvaluationTest{
+		{
+			Name:     "Line comment",
+			Input:    "1 + 2 // three",
+			Expected: 3.0,
+		},
+		{
+			Name:     "Block comment",
+			Input:    "1 + /* not three */ 2",
+			Expected: 3.0,
+		},
+		{
+			Name:     "Comment directly after symbol",
+			Input:    "1 +// comment\n2",
+			Expected: 3.0,
+		},
+		{
+			Name:     "Comment markers within string",
+			Input:    "'http://example.com/*' + \"//\"",
+			Expected: "http://example.com/*//",
+		},
+		{
+			Name:     "Division is not a comment",
+			Input:    "10 / 2/5",
+			Expected: 1.0,
+		},
+		{
+			Name: "Multi-line rule",
+			Input: `// only large orders qualify
+				total > 100 &&
+				/* and only for
+				   existing customers */
+				customer == 'existing' // not 'new'`,
+			Parameters: []EvaluationParameter{
+				{Name: "total", Value: 150},
+				{Name: "customer", Value: "existing"},
+			},
+			Expected: true,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+	}
+
+func TestStringLiteralEvaluation(test *testing.T) {
+
+	evaluationTests := []EvaluationTest{
+		{
+			Name:     "Raw string regex",
+			Input:    "'3.14' =~ `^\\d+\\.\\d+$`",
+			Expected: true,
+		},
+		{
+			Name:     "Escaped regex",
+			Input:    "'3x14' =~ '^\\\\d+\\\\.\\\\d+$'",
+			Expected: false,
+		},
+		{
+			Name:     "Escaped newline concatenation",
+			Input:    "'a' + '\\n' + \"b\" == \"a\\nb\"",
+			Expected: true,
+		},
+		{
+			Name:     "Mixed quotes",
+			Input:    "\"it's\" + ' \"quoted\"'",
+			Expected: "it's \"quoted\"",
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+}
+
+func TestNullEvaluation(test *testing.T) {
+
+	var nilPointer *dummyNestedParameter
+
+	evaluationTests := []EvaluationTest{
+		{
+			Name:     "Null literal",
+			Input:    "null",
+			Expected: nil,
+		},
+		{
+			Name:     "Null equality",
+			Input:    "null == null",
+			Expected: true,
+		},
+		{
+			Name:       "Nil parameter equals null",
+			Input:      "foo == null",
+			Parameters: []EvaluationParameter{{Name: "foo", Value: nil}},
+			Expected:   true,
+		},
+		{
+			Name:       "Nil pointer parameter equals null",
+			Input:      "foo == null",
+			Parameters: []EvaluationParameter{{Name: "foo", Value: nilPointer}},
+			Expected:   true,
+		},
+		{
+			Name:       "Parameter not equal to null",
+			Input:      "foo != null",
+			Parameters: []EvaluationParameter{{Name: "foo", Value: 0}},
+			Expected:   true,
+		},
+		{
+			Name:     "Null coalesce literal",
+			Input:    "null ?? 'default'",
+			Expected: "default",
+		},
+		{
+			Name:       "Nil pointer coalesce",
+			Input:      "foo ?? 'default'",
+			Parameters: []EvaluationParameter{{Name: "foo", Value: nilPointer}},
+			Expected:   "default",
+		},
+		{
+			Name:     "Null in ternary",
+			Input:    "true ? null : 1",
+			Expected: nil,
+		},
+		{
+			Name:       "Null parameter in ternary",
+			Input:      "vip ? nothing : 1",
+			Parameters: []EvaluationParameter{{Name: "vip", Value: true}, {Name: "nothing", Value: nil}},
+			Expected:   nil,
+		},
+		{
+			Name:       "Null parameter in parenthesized ternary",
+			Input:      "(vip ? nothing) : 1",
+			Parameters: []EvaluationParameter{{Name: "vip", Value: true}, {Name: "nothing", Value: nil}},
+			Expected:   nil,
+		},
+		{
+			Name:       "Else of ternary with null branch",
+			Input:      "vip ? nothing : 1",
+			Parameters: []EvaluationParameter{{Name: "vip", Value: false}, {Name: "nothing", Value: nil}},
+			Expected:   1.0,
+		},
+		{
+			Name:     "Ternary without else",
+			Input:    "(1 > 2 ? 'a') == null",
+			Expected: true,
+		},
+		{
+			Name:       "Optional accessor on nil field",
+			Input:      "foo.NestedPtr?.Funk",
+			Parameters: []EvaluationParameter{fooParameter},
+			Expected:   nil,
+		},
+		{
+			Name:       "Optional accessor on non-nil field",
+			Input:      "foo?.Nested?.Funk",
+			Parameters: []EvaluationParameter{fooParameter},
+			Expected:   "funkalicious",
+		},
+		{
+			Name:       "Optional accessor on nil parameter with coalesce",
+			Input:      "foo?.Nested.Funk ?? 'none'",
+			Parameters: []EvaluationParameter{{Name: "foo", Value: nilPointer}},
+			Expected:   "none",
+		},
+		{
+			Name:       "Optional accessor compared to null",
+			Input:      "foo.NestedPtr?.Dunk('x') == null",
+			Parameters: []EvaluationParameter{fooParameter},
+			Expected:   true,
+		},
+		{
+			Name:       "Optional accessor on pointer parameter",
+			Input:      "fooptr?.String",
+			Parameters: []EvaluationParameter{fooPtrParameter},
+			Expected:   "string!",
+		},
+		{
+			Name:       "Method call followed by comparison",
+			Input:      "foo.Dunk('x') == 'xdunk'",
+			Parameters: []EvaluationParameter{{Name: "foo", Value: dummyNestedParameter{}}},
+			Expected:   true,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+}
+
+func TestLambdaEvaluation(test *testing.T) {
+
+	numbers := EvaluationParameter{
+		Name:  "numbers",
+		Value: []interface{}{1, 5, 10, 50},
+	}
+
+	evaluationTests := []EvaluationTest{
+		{
+			Name:     "Any with literal array",
+			Input:    "any((1, 2, 3), x => x > 2)",
+			Expected: true,
+		},
+		{
+			Name:     "Any without match",
+			Input:    "any((1, 2, 3), x => x > 3)",
+			Expected: false,
+		},
+		{
+			Name:       "All with parameter array",
+			Input:      "all(numbers, n => n >= 1)",
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   true,
+		},
+		{
+			Name:       "All short-circuits",
+			Input:      "all(numbers, n => n < 5)",
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   false,
+		},
+		{
+			Name:       "Count with lambda",
+			Input:      "count(numbers, n => n > 4)",
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   3.0,
+		},
+		{
+			Name:       "Count without lambda",
+			Input:      "count(numbers)",
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   4.0,
+		},
+		{
+			Name:       "Sum without lambda",
+			Input:      "sum(numbers)",
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   66.0,
+		},
+		{
+			Name:       "Sum with lambda",
+			Input:      "sum(numbers, n => n * 2)",
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   132.0,
+		},
+		{
+			Name:       "Count of filter",
+			Input:      "count(filter(numbers, n => n % 2 == 0))",
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   2.0,
+		},
+		{
+			Name:     "Sum of map",
+			Input:    "sum(map((1, 2, 3), x => x ** 2))",
+			Expected: 14.0,
+		},
+		{
+			Name:       "Membership in map",
+			Input:      "'third!' in map(items, i => i.Funk + '!')",
+			Parameters: []EvaluationParameter{itemsParameter},
+			Expected:   true,
+		},
+		{
+			Name:       "Accessor within lambda over slice parameter",
+			Input:      "any(items, i => i.Funk == 'second')",
+			Parameters: []EvaluationParameter{itemsParameter},
+			Expected:   true,
+		},
+		{
+			Name:  "Lambda referencing outer parameter",
+			Input: "count(numbers, n => n > limit)",
+			Parameters: []EvaluationParameter{
+				numbers,
+				{Name: "limit", Value: 5},
+			},
+			Expected: 2.0,
+		},
+		{
+			Name:       "Lambda parameter shadows outer parameter",
+			Input:      "sum(numbers, n => n) + n",
+			Parameters: []EvaluationParameter{numbers, {Name: "n", Value: 1}},
+			Expected:   67.0,
+		},
+		{
+			Name:       "Nested lambdas",
+			Input:      "count(numbers, n => any(numbers, m => m == n * 10))",
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   2.0,
+		},
+		{
+			Name:       "Lambda with ternary body",
+			Input:      "sum(numbers, n => n > 5 ? n : 0)",
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   60.0,
+		},
+		{
+			Name:       "Builtin name used as a parameter",
+			Input:      "count + sum",
+			Parameters: []EvaluationParameter{{Name: "count", Value: 1}, {Name: "sum", Value: 2}},
+			Expected:   3.0,
+		},
+		{
+			Name:  "User function overrides builtin",
+			Input: "any(numbers, n => n > 100)",
+			Functions: map[string]ExpressionFunction{
+				"any": func(arguments ...interface{}) (interface{}, error) {
+					return "overridden", nil
+				},
+			},
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   "overridden",
+		},
+		{
+			Name:  "User function calling a lambda",
+			Input: "apply(x => x + 1, 41)",
+			Functions: map[string]ExpressionFunction{
+				"apply": func(arguments ...interface{}) (interface{}, error) {
+					return arguments[0].(ExpressionLambda).Call(arguments[1])
+				},
+			},
+			Expected: 42.0,
+		},
+		{
+			Name:  "Array parameter is not flattened into other arguments",
+			Input: "first(numbers, 2)",
+			Functions: map[string]ExpressionFunction{
+				"first": func(arguments ...interface{}) (interface{}, error) {
+					return len(arguments), nil
+				},
+			},
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   2,
+		},
+		{
+			Name:  "Array parameter is passed whole alongside other arguments",
+			Input: "first(numbers, 2)",
+			Functions: map[string]ExpressionFunction{
+				"first": func(arguments ...interface{}) (interface{}, error) {
+					return len(arguments[0].([]interface{})), nil
+				},
+			},
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   4,
+		},
+		{
+			Name:  "Array parameter alone is spread into arguments",
+			Input: "count(numbers)",
+			Functions: map[string]ExpressionFunction{
+				"count": func(arguments ...interface{}) (interface{}, error) {
+					return len(arguments), nil
+				},
+			},
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   4,
+		},
+		{
+			Name:       "Membership doesn't look inside an array parameter in a list",
+			Input:      "1 in (arr, 3)",
+			Parameters: []EvaluationParameter{{Name: "arr", Value: []interface{}{1.0, 2.0}}},
+			Expected:   false,
+		},
+		{
+			Name:       "Membership of a value after an array parameter in a list",
+			Input:      "3 in (arr, 3)",
+			Parameters: []EvaluationParameter{{Name: "arr", Value: []interface{}{1.0, 2.0}}},
+			Expected:   true,
+		},
+		{
+			Name:  "Array parameter in a parenthesized list is a single element",
+			Input: "count((numbers, 2))",
+			Functions: map[string]ExpressionFunction{
+				"count": func(arguments ...interface{}) (interface{}, error) {
+					return len(arguments), nil
+				},
+			},
+			Parameters: []EvaluationParameter{numbers},
+			Expected:   2,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+}
+
+func TestLetBindingEvaluation(test *testing.T) {
+
+	pricing := []EvaluationParameter{
+		{Name: "price", Value: 30},
+		{Name: "qty", Value: 5},
+		{Name: "discount", Value: 20},
+	}
+
+	evaluationTests := []EvaluationTest{
+		{
+			Name:       "Single binding",
+			Input:      "let total = price * qty - discount; total > 100 && total < 1000",
+			Parameters: pricing,
+			Expected:   true,
+		},
+		{
+			Name:       "Binding visible to later bindings",
+			Input:      "let subtotal = price * qty; let total = subtotal - discount; total",
+			Parameters: pricing,
+			Expected:   130.0,
+		},
+		{
+			Name:       "Binding shadows parameter",
+			Input:      "let price = price * 2; price",
+			Parameters: pricing,
+			Expected:   60.0,
+		},
+		{
+			Name:     "Binding within a clause",
+			Input:    "(let a = 2; a * a) + 1",
+			Expected: 5.0,
+		},
+		{
+			Name:     "Binding an array",
+			Input:    "let allowed = ('a', 'b'); 'b' in allowed",
+			Expected: true,
+		},
+		{
+			Name:     "Binding used by a lambda",
+			Input:    "let limit = 2; count((1, 2, 3, 4), x => x > limit)",
+			Expected: 2.0,
+		},
+		{
+			Name:       "Let as a parameter name",
+			Input:      "let == 1",
+			Parameters: []EvaluationParameter{{Name: "let", Value: 1}},
+			Expected:   true,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+}
+
+/*
+	Tests that the value of a let binding is only evaluated once, no matter how many times it's used.
+*/
+func TestLetBindingEvaluatedOnce(test *testing.T) {
+
+	calls := 0
+	functions := map[string]ExpressionFunction{
+		"expensive": func(arguments ...interface{}) (interface{}, error) {
+			calls++
+			return 10.0, nil
+		},
+	}
+
+	expression, err := NewEvaluableExpressionWithFunctions("let x = expensive(); x + x + x", functions)
+	if err != nil {
+		test.Logf("Failed to parse let binding: %v", err)
+		test.FailNow()
+	}
+
+	result, err := expression.Evaluate(nil)
+	if err != nil {
+		test.Logf("Failed to evaluate let binding: %v", err)
+		test.FailNow()
+	}
+
+	if result != 30.0 || calls != 1 {
+		test.Logf("Expected result 30 from one call, got %v from %d calls", result, calls)
+		test.Fail()
+	}
+}
+
+func TestSharedSubexpressions(test *testing.T) {
+
+	calls := make(map[string]int)
+	counted := func(name string) ExpressionFunction {
+		return func(arguments ...interface{}) (interface{}, error) {
+			calls[name]++
+			return arguments[0].(float64) * 2, nil
+		}
+	}
+
+	env := NewEnv()
+	env.Functions = map[string]ExpressionFunction{
+		"pure":   counted("pure"),
+		"impure": counted("impure"),
+	}
+	env.PureFunctions = map[string]bool{"pure": true}
+
+	expression, err := env.Compile("(price * qty > 10 ? pure(price * qty) : 0) + pure(price * qty) + impure(qty) + impure(qty)")
+	if err != nil {
+		test.Logf("Failed to compile expression: %v", err)
+		test.FailNow()
+	}
+
+	parameters := countingParameters{
+		values: map[string]interface{}{"price": 3, "qty": 4},
+		reads:  make(map[string]int),
+	}
+
+	for i := 0; i < 2; i++ {
+
+		result, err := expression.Eval(parameters)
+		if err != nil || result != 64.0 {
+			test.Logf("Expected 64, got %v (error %v)", result, err)
+			test.FailNow()
+		}
+	}
+
+	// results are only kept for a single evaluation.
+	expected := map[string]int{"pure": 2, "impure": 4, "price": 2, "qty": 2}
+	actual := map[string]int{"pure": calls["pure"], "impure": calls["impure"], "price": parameters.reads["price"], "qty": parameters.reads["qty"]}
+
+	if !reflect.DeepEqual(expected, actual) {
+		test.Logf("Expected calls and reads %v, got %v", expected, actual)
+		test.Fail()
+	}
+}
+
+/*
+	Tests that stages which look alike, but may not evaluate to the same thing, aren't shared.
+*/
+func TestSharedSubexpressionsDiffer(test *testing.T) {
+
+	evaluationTests := []EvaluationTest{
+		{
+			Name:  "Lists with a shared beginning",
+			Input: "(a, b, c, 1) == (a, b, c, 2)",
+			Parameters: []EvaluationParameter{
+				{Name: "a", Value: 1},
+				{Name: "b", Value: 2},
+				{Name: "c", Value: 3},
+			},
+			Expected: false,
+		},
+		{
+			Name:  "Parameter named like an accessor",
+			Input: "[foo.Int] + foo.Int",
+			Parameters: []EvaluationParameter{
+				{Name: "foo.Int", Value: 1},
+				{Name: "foo", Value: dummyParameter{Int: 2}},
+			},
+			Expected: 3.0,
+		},
+		{
+			Name:       "Repeated method call",
+			Input:      "foo.Next == foo.Next",
+			Parameters: []EvaluationParameter{{Name: "foo", Value: &dummyCounter{}}},
+			Expected:   false,
+		},
+		{
+			Name:       "Repeated method call within a larger stage",
+			Input:      "foo.Next * 2 + (foo.Next * 2)",
+			Parameters: []EvaluationParameter{{Name: "foo", Value: &dummyCounter{}}},
+			Expected:   6.0,
+		},
+		{
+			Name:       "Repeated field",
+			Input:      "foo.Count + foo.Count",
+			Parameters: []EvaluationParameter{{Name: "foo", Value: &dummyCounter{Count: 2}}},
+			Expected:   4.0,
+		},
+		{
+			Name:       "Ternary with and without an else",
+			Input:      "(a ? b : 1) == 1 && (a ? b) == null",
+			Parameters: []EvaluationParameter{{Name: "a", Value: false}, {Name: "b", Value: 2}},
+			Expected:   true,
+		},
+	}
+
+	runEvaluationTests(evaluationTests, test)
+}
+
+func TestLazyParameters(test *testing.T) {
+
+	var fooCalls, barCalls, failCalls int32
+
+	parameters := LazyParameters{
+		"foo": func() (interface{}, error) {
+			atomic.AddInt32(&fooCalls, 1)
+			return 2, nil
+		},
+		"bar": func() (interface{}, error) {
+			atomic.AddInt32(&barCalls, 1)
+			return "bar", nil
+		},
+		"fail": func() (interface{}, error) {
+			atomic.AddInt32(&failCalls, 1)
+			return nil, errors.New("unavailable")
+		},
+	}
+
+	expression, err := NewEvaluableExpression("foo * foo > 3 || bar == 'bar' || fail")
+	if err != nil {
+		test.Logf("Failed to parse expression: %v", err)
+		test.FailNow()
+	}
+
+	var wait sync.WaitGroup
+	for i := 0; i < 8; i++ {
+
+		wait.Add(1)
+		go func() {
+
+			defer wait.Done()
+
+			result, err := expression.Eval(parameters)
+			if err != nil || result != true {
+				test.Logf("Expected true, got %v (error %v)", result, err)
+				test.Fail()
+			}
+		}()
+	}
+	wait.Wait()
+
+	if fooCalls != 8 || barCalls != 0 || failCalls != 0 {
+		test.Logf("Expected one resolution of foo per evaluation and none of the others, got foo %d, bar %d, fail %d", fooCalls, barCalls, failCalls)
+		test.Fail()
+	}
+
+	// errors are also only resolved once.
+	memoized := parameters.Memoized()
+	memoized.Get("fail")
+
+	_, err = memoized.Get("fail")
+	if err == nil || err.Error() != "unavailable" || failCalls != 1 {
+		test.Logf("Expected the error of one resolution, got '%v' from %d", err, failCalls)
+		test.Fail()
+	}
+
+	expression, _ = NewEvaluableExpression("missing > 1")
+
+	_, err = expression.Eval(parameters)
+	if err == nil || err.Error() != "No parameter 'missing' found." {
+		test.Logf("Expected an error for a missing parameter, got %v", err)
+		test.Fail()
+	}
+}
+
 /*
 	Tests the behavior of a nil set of parameters.
 */
@@ -1330,3 +1963,39 @@ func runEvaluationTests(evaluationTests []EvaluationTest, test *testing.T) {
 		}
 	}
 }
+
+/*
+	Tests that evaluating an expression only allocates what its parameters need, so that the common case stays cheap.
+*/
+func TestEvaluationAllocations(test *testing.T) {
+
+	cases := []struct {
+		input      string
+		parameters Parameters
+		expected   float64
+	}{
+		{input: "1 + 2 > 2", expected: 0},
+		{input: "'a' != 'b' && true", expected: 0},
+		{input: "foo > 1 && bar", parameters: MapParameters{"foo": 2.0, "bar": true}, expected: 1},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpression(testCase.input)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		allocations := testing.AllocsPerRun(100, func() {
+			expression.Eval(testCase.parameters)
+		})
+
+		if allocations != testCase.expected {
+			test.Logf("Test '%s' failed", testCase.input)
+			test.Logf("Expected %v allocations per evaluation, got %v", testCase.expected, allocations)
+			test.Fail()
+		}
+	}
+}
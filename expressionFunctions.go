@@ -1,8 +1,43 @@
 package govaluate
 
+import (
+	"sync"
+)
+
 /*
 	Represents a function that can be called from within an expression.
 	This method must return an error if, for any reason, it is unable to produce exactly one unambiguous result.
 	An error returned will halt execution of the expression.
 */
 type ExpressionFunction func(arguments ...interface{}) (interface{}, error)
+
+var registeredFunctions = make(map[string]ExpressionFunction)
+var registeredFunctionsLock sync.RWMutex
+
+/*
+	Registers a function that every `Expression` can use when it's unmarshaled or scanned,
+	unless that `Expression` was given its own `Functions`.
+	Meant to be called during program initialization, before any configuration is loaded.
+*/
+func RegisterFunction(name string, function ExpressionFunction) {
+
+	registeredFunctionsLock.Lock()
+	defer registeredFunctionsLock.Unlock()
+
+	registeredFunctions[name] = function
+}
+
+/*
+	Returns a copy of all functions given to `RegisterFunction`, which is safe to use while more are registered.
+*/
+func getRegisteredFunctions() map[string]ExpressionFunction {
+
+	registeredFunctionsLock.RLock()
+	defer registeredFunctionsLock.RUnlock()
+
+	ret := make(map[string]ExpressionFunction, len(registeredFunctions))
+	for name, function := range registeredFunctions {
+		ret[name] = function
+	}
+	return ret
+}
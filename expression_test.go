@@ -0,0 +1,237 @@
+package govaluate
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var _ encoding.TextMarshaler = Expression{}
+var _ encoding.TextUnmarshaler = &Expression{}
+var _ encoding.BinaryMarshaler = Expression{}
+var _ encoding.BinaryUnmarshaler = &Expression{}
+var _ json.Marshaler = Expression{}
+var _ json.Unmarshaler = &Expression{}
+var _ sql.Scanner = &Expression{}
+var _ driver.Valuer = Expression{}
+
+type expressionConfig struct {
+	Name      string
+	Condition Expression
+	Fallback  Expression
+}
+
+func TestExpressionJSON(test *testing.T) {
+
+	var config expressionConfig
+
+	err := json.Unmarshal([]byte(`{"Name": "large", "Condition": "total > 100", "Fallback": null}`), &config)
+	if err != nil {
+		test.Logf("Failed to unmarshal config: %v", err)
+		test.FailNow()
+	}
+
+	if config.Fallback.EvaluableExpression != nil {
+		test.Logf("Expected null expression to be nil")
+		test.Fail()
+	}
+
+	result, err := config.Condition.Evaluate(map[string]interface{}{"total": 150})
+	if err != nil || result != true {
+		test.Logf("Unmarshaled expression gave '%v', %v", result, err)
+		test.Fail()
+	}
+
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		test.Logf("Failed to marshal config: %v", err)
+		test.FailNow()
+	}
+
+	expected := `{"Name":"large","Condition":"total \u003e 100","Fallback":null}`
+	if string(encoded) != expected {
+		test.Logf("Marshaled config was %s, expected %s", encoded, expected)
+		test.Fail()
+	}
+}
+
+func TestExpressionText(test *testing.T) {
+
+	var expression Expression
+
+	err := expression.UnmarshalText([]byte("1 + 2"))
+	if err != nil {
+		test.Logf("Failed to unmarshal text: %v", err)
+		test.FailNow()
+	}
+
+	text, _ := expression.MarshalText()
+	if string(text) != "1 + 2" {
+		test.Logf("Marshaled text was '%s'", text)
+		test.Fail()
+	}
+
+	err = expression.UnmarshalText([]byte{})
+	if err != nil || expression.EvaluableExpression != nil {
+		test.Logf("Expected empty text to give a nil expression, got %v", err)
+		test.Fail()
+	}
+
+	text, _ = expression.MarshalText()
+	if len(text) != 0 {
+		test.Logf("Marshaled empty expression was '%s'", text)
+		test.Fail()
+	}
+}
+
+func TestExpressionBinary(test *testing.T) {
+
+	var empty Expression
+
+	data, err := empty.MarshalBinary()
+	if err != nil || len(data) != 0 {
+		test.Logf("Expected a zero expression to marshal to nothing, got %v, %v", data, err)
+		test.Fail()
+	}
+
+	err = empty.UnmarshalBinary(data)
+	if err != nil || empty.EvaluableExpression != nil {
+		test.Logf("Expected no data to unmarshal to a nil expression, got %v, %v", empty.EvaluableExpression, err)
+		test.Fail()
+	}
+
+	functions := map[string]ExpressionFunction{
+		"double": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0].(float64) * 2, nil
+		},
+	}
+
+	condition, _ := NewEvaluableExpressionWithFunctions("double(total) > 100", functions)
+
+	data, err = Expression{EvaluableExpression: condition}.MarshalBinary()
+	if err != nil {
+		test.Logf("Failed to marshal expression: %v", err)
+		test.FailNow()
+	}
+
+	decoded := Expression{Functions: functions}
+
+	err = decoded.UnmarshalBinary(data)
+	if err != nil {
+		test.Logf("Failed to unmarshal expression: %v", err)
+		test.FailNow()
+	}
+
+	result, err := decoded.Evaluate(map[string]interface{}{"total": 60})
+	if err != nil || result != true {
+		test.Logf("Unmarshaled expression gave '%v', %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestExpressionSQL(test *testing.T) {
+
+	var expression Expression
+
+	for _, column := range []interface{}{"foo == 'bar'", []byte("foo == 'bar'")} {
+
+		err := expression.Scan(column)
+		if err != nil {
+			test.Logf("Failed to scan %T: %v", column, err)
+			test.Fail()
+			continue
+		}
+
+		value, _ := expression.Value()
+		if value != "foo == 'bar'" {
+			test.Logf("Value of scanned %T was '%v'", column, value)
+			test.Fail()
+		}
+	}
+
+	err := expression.Scan(nil)
+	if err != nil || expression.EvaluableExpression != nil {
+		test.Logf("Expected NULL to give a nil expression, got %v", err)
+		test.Fail()
+	}
+
+	value, _ := expression.Value()
+	if value != nil {
+		test.Logf("Value of nil expression was '%v'", value)
+		test.Fail()
+	}
+
+	err = expression.Scan(10)
+	if err == nil || !strings.Contains(err.Error(), "of type int") {
+		test.Logf("Expected scanning an int to fail, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestExpressionFunctions(test *testing.T) {
+
+	RegisterFunction("expressionTestRegistered", func(arguments ...interface{}) (interface{}, error) {
+		return "registered", nil
+	})
+
+	expression, err := ParseExpression("expressionTestRegistered()")
+	if err != nil {
+		test.Logf("Failed to parse with registered function: %v", err)
+		test.FailNow()
+	}
+
+	result, _ := expression.Evaluate(nil)
+	if result != "registered" {
+		test.Logf("Registered function gave '%v'", result)
+		test.Fail()
+	}
+
+	// functions given to the expression take the place of registered functions.
+	config := expressionConfig{
+		Condition: Expression{
+			Functions: map[string]ExpressionFunction{
+				"supplied": func(arguments ...interface{}) (interface{}, error) {
+					return "supplied", nil
+				},
+			},
+		},
+	}
+
+	err = json.Unmarshal([]byte(`{"Condition": "supplied()"}`), &config)
+	if err != nil {
+		test.Logf("Failed to parse with supplied function: %v", err)
+		test.FailNow()
+	}
+
+	result, _ = config.Condition.Evaluate(nil)
+	if result != "supplied" {
+		test.Logf("Supplied function gave '%v'", result)
+		test.Fail()
+	}
+
+	err = json.Unmarshal([]byte(`{"Condition": "expressionTestRegistered()"}`), &config)
+	if err == nil || !strings.Contains(err.Error(), "Undefined function expressionTestRegistered") {
+		test.Logf("Expected registered functions to be unavailable, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestExpressionInvalid(test *testing.T) {
+
+	var config expressionConfig
+
+	err := json.Unmarshal([]byte(`{"Condition": "total >"}`), &config)
+	if err == nil || !strings.Contains(err.Error(), "Unable to parse expression 'total >'") {
+		test.Logf("Expected invalid expression to fail, got %v", err)
+		test.Fail()
+	}
+
+	err = json.Unmarshal([]byte(`{"Condition": 10}`), &config)
+	if err == nil || !strings.Contains(err.Error(), "must be a JSON string") {
+		test.Logf("Expected a number to fail, got %v", err)
+		test.Fail()
+	}
+}
@@ -0,0 +1,185 @@
+package govaluate
+
+import (
+	"time"
+)
+
+/*
+	EvaluationHooks are called as an expression is evaluated, so that what it does can be measured or logged.
+	They're installed by setting the `Hooks` of an expression (or of the Env which compiles it).
+	When no hooks are installed, evaluation doesn't do any extra work.
+
+	Hooks are called from whichever goroutine is evaluating, so if an expression is evaluated concurrently, its hooks must be safe
+	to call concurrently. Embed `BaseHooks` to only implement some of the methods.
+*/
+type EvaluationHooks interface {
+
+	/*
+		Called before each part of the expression is evaluated. Parts which are skipped by short-circuiting aren't entered.
+	*/
+	StageEnter(stage HookStage)
+
+	/*
+		Called after each part of the expression has been evaluated, with its [result] or the [err] it failed with.
+	*/
+	StageExit(stage HookStage, result interface{}, err error)
+
+	/*
+		Called each time a parameter is read, with its [value] or the [err] it couldn't be read with.
+	*/
+	ParameterLookup(name string, value interface{}, err error)
+
+	/*
+		Called after each function call, with how long the function took, and the error it returned (if any).
+		The [name] is empty for functions whose names aren't known; see `NewEvaluableExpressionFromTokens`.
+	*/
+	FunctionCall(name string, duration time.Duration, err error)
+
+	/*
+		Called once for each evaluation which fails, with the error it returns.
+	*/
+	Error(err error)
+}
+
+/*
+	EvaluationHooks which do nothing. Meant to be embedded by hooks which only need some of the methods.
+*/
+type BaseHooks struct{}
+
+func (BaseHooks) StageEnter(stage HookStage)                                  {}
+func (BaseHooks) StageExit(stage HookStage, result interface{}, err error)    {}
+func (BaseHooks) ParameterLookup(name string, value interface{}, err error)   {}
+func (BaseHooks) FunctionCall(name string, duration time.Duration, err error) {}
+func (BaseHooks) Error(err error)                                             {}
+
+/*
+	A part of an expression which is being evaluated, given to hooks.
+*/
+type HookStage struct {
+	stage *evaluationStage
+}
+
+/*
+	Returns the operator of this part of the expression, such as ">" or "&&",
+	"()" for a function call, or an empty string for a single value such as a parameter.
+*/
+func (stage HookStage) Operator() string {
+
+	switch stage.stage.symbol {
+	case VALUE, LITERAL, ACCESS:
+		return ""
+	case FUNCTIONAL:
+		return "()"
+	case SEPARATE:
+		return ","
+	}
+	return formatSymbol(stage.stage.symbol)
+}
+
+/*
+	Returns this part of the expression as it would be written, such as `age > 18`.
+	This isn't kept with the expression, so it's built each time it's called.
+*/
+func (stage HookStage) String() string {
+	return formatStage(stage.stage)
+}
+
+/*
+	Calls all of the given hooks, in order.
+*/
+type chainedHooks []EvaluationHooks
+
+func (hooks chainedHooks) StageEnter(stage HookStage) {
+	for _, hook := range hooks {
+		hook.StageEnter(stage)
+	}
+}
+
+func (hooks chainedHooks) StageExit(stage HookStage, result interface{}, err error) {
+	for _, hook := range hooks {
+		hook.StageExit(stage, result, err)
+	}
+}
+
+func (hooks chainedHooks) ParameterLookup(name string, value interface{}, err error) {
+	for _, hook := range hooks {
+		hook.ParameterLookup(name, value, err)
+	}
+}
+
+func (hooks chainedHooks) FunctionCall(name string, duration time.Duration, err error) {
+	for _, hook := range hooks {
+		hook.FunctionCall(name, duration, err)
+	}
+}
+
+func (hooks chainedHooks) Error(err error) {
+	for _, hook := range hooks {
+		hook.Error(err)
+	}
+}
+
+/*
+	Returns hooks which call both of the given hooks, either of which may be nil.
+*/
+func chainHooks(first, second EvaluationHooks) EvaluationHooks {
+
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+	return chainedHooks{first, second}
+}
+
+// hookedParameters is a wrapper for Parameters which calls hooks each time a parameter is read.
+type hookedParameters struct {
+	orig  Parameters
+	hooks EvaluationHooks
+}
+
+func (p hookedParameters) Get(name string) (interface{}, error) {
+
+	value, err := p.orig.Get(name)
+	p.hooks.ParameterLookup(name, value, err)
+	return value, err
+}
+
+/*
+	Evaluates the given [stage], calling this expression's hooks before and after.
+*/
+func (expr *EvaluableExpression) hookStage(stage *evaluationStage, parameters Parameters) (interface{}, error) {
+
+	// parenthesis aren't a part of the expression that hooks are told about, just what's in them.
+	if stage.symbol == NOOP {
+		return expr.evaluateSharedStage(stage, parameters)
+	}
+
+	hooked := HookStage{stage}
+
+	expr.Hooks.StageEnter(hooked)
+	result, err := expr.evaluateSharedStage(stage, parameters)
+
+	// hooks are told that the condition of a ternary evaluated to null when it was false, as it would have without an else.
+	if result == ternaryUnmatched {
+		expr.Hooks.StageExit(hooked, nil, err)
+		return result, err
+	}
+
+	expr.Hooks.StageExit(hooked, result, err)
+
+	return result, err
+}
+
+/*
+	Calls the function of the given [stage], calling this expression's hooks afterwards.
+*/
+func (expr *EvaluableExpression) hookFunction(stage *evaluationStage, left, right interface{}, parameters Parameters) (interface{}, error) {
+
+	start := time.Now()
+	result, err := stage.operator(left, right, parameters)
+
+	expr.Hooks.FunctionCall(stage.reference, time.Since(start), err)
+	return result, err
+}
@@ -0,0 +1,54 @@
+package govaluate
+
+import (
+	"expvar"
+	"time"
+)
+
+/*
+	EvaluationHooks which count what expressions do, in an `expvar.Map` so that they're published with the program's other variables.
+	Install separate ExpvarHooks (with separate maps) on each expression or rule to count them separately.
+
+	The map has these counters, which are created as they're needed:
+
+	parameters.<name>            the number of times the parameter was read
+	functions.<name>.calls       the number of times the function was called
+	functions.<name>.errors      the number of times the function returned an error
+	functions.<name>.nanoseconds the total time spent in the function
+	errors                       the number of evaluations which failed
+*/
+type ExpvarHooks struct {
+	BaseHooks
+	Map *expvar.Map
+}
+
+/*
+	Returns hooks which count into the given [counters], such as one returned by `expvar.NewMap`.
+*/
+func NewExpvarHooks(counters *expvar.Map) *ExpvarHooks {
+	return &ExpvarHooks{
+		Map: counters,
+	}
+}
+
+func (hooks *ExpvarHooks) ParameterLookup(name string, value interface{}, err error) {
+	hooks.Map.Add("parameters."+name, 1)
+}
+
+func (hooks *ExpvarHooks) FunctionCall(name string, duration time.Duration, err error) {
+
+	if name == "" {
+		name = "unknown"
+	}
+
+	hooks.Map.Add("functions."+name+".calls", 1)
+	hooks.Map.Add("functions."+name+".nanoseconds", int64(duration))
+
+	if err != nil {
+		hooks.Map.Add("functions."+name+".errors", 1)
+	}
+}
+
+func (hooks *ExpvarHooks) Error(err error) {
+	hooks.Map.Add("errors", 1)
+}
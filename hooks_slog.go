@@ -0,0 +1,63 @@
+//go:build go1.21
+// +build go1.21
+
+package govaluate
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+/*
+	EvaluationHooks which log what expressions do to a `slog.Logger`.
+	Parameter lookups and function calls are logged at `Level`, and failed evaluations at `slog.LevelError`.
+	Parts of expressions being evaluated aren't logged, since there are so many of them; use `EvalWithTrace` to see those.
+*/
+type SlogHooks struct {
+	BaseHooks
+	Logger *slog.Logger
+	Level  slog.Level
+}
+
+/*
+	Returns hooks which log to the given [logger], with parameter lookups and function calls at the debug level.
+*/
+func NewSlogHooks(logger *slog.Logger) *SlogHooks {
+	return &SlogHooks{
+		Logger: logger,
+		Level:  slog.LevelDebug,
+	}
+}
+
+func (hooks *SlogHooks) ParameterLookup(name string, value interface{}, err error) {
+
+	ctx := context.Background()
+	if !hooks.Logger.Enabled(ctx, hooks.Level) {
+		return
+	}
+
+	if err != nil {
+		hooks.Logger.LogAttrs(ctx, hooks.Level, "Parameter lookup failed", slog.String("name", name), slog.String("error", err.Error()))
+		return
+	}
+	hooks.Logger.LogAttrs(ctx, hooks.Level, "Parameter lookup", slog.String("name", name), slog.Any("value", value))
+}
+
+func (hooks *SlogHooks) FunctionCall(name string, duration time.Duration, err error) {
+
+	ctx := context.Background()
+	if !hooks.Logger.Enabled(ctx, hooks.Level) {
+		return
+	}
+
+	if err != nil {
+		hooks.Logger.LogAttrs(ctx, hooks.Level, "Function call failed", slog.String("name", name), slog.Duration("duration", duration), slog.String("error", err.Error()))
+		return
+	}
+	hooks.Logger.LogAttrs(ctx, hooks.Level, "Function call", slog.String("name", name), slog.Duration("duration", duration))
+}
+
+func (hooks *SlogHooks) Error(err error) {
+	hooks.Logger.LogAttrs(context.Background(), slog.LevelError, "Evaluation failed", slog.String("error", err.Error()))
+}
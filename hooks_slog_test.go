@@ -0,0 +1,36 @@
+//go:build go1.21
+// +build go1.21
+
+package govaluate
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHooks(test *testing.T) {
+
+	var output bytes.Buffer
+
+	env := NewEnv()
+	env.Hooks = NewSlogHooks(slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	expression, _ := env.Compile("count(names) > limit")
+	expression.Evaluate(map[string]interface{}{"names": []interface{}{"govaluate"}})
+
+	logged := output.String()
+
+	for _, expected := range []string{
+		`msg="Parameter lookup" name=names value=[govaluate]`,
+		`msg="Function call" name=count`,
+		`msg="Parameter lookup failed" name=limit`,
+		`level=ERROR msg="Evaluation failed"`,
+	} {
+		if !strings.Contains(logged, expected) {
+			test.Logf("Expected log to contain %s, got:\n%s", expected, logged)
+			test.Fail()
+		}
+	}
+}
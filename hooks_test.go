@@ -0,0 +1,203 @@
+package govaluate
+
+import (
+	"errors"
+	"expvar"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*
+	Hooks which record what they're called with, as lines of text.
+*/
+type recordingHooks struct {
+	calls []string
+	depth int
+}
+
+func (hooks *recordingHooks) StageEnter(stage HookStage) {
+	hooks.calls = append(hooks.calls, strings.Repeat("  ", hooks.depth)+"enter "+stage.String())
+	hooks.depth++
+}
+
+func (hooks *recordingHooks) StageExit(stage HookStage, result interface{}, err error) {
+	hooks.depth--
+	hooks.calls = append(hooks.calls, strings.Repeat("  ", hooks.depth)+"exit "+stage.Operator()+" "+formatLiteral(result))
+}
+
+func (hooks *recordingHooks) ParameterLookup(name string, value interface{}, err error) {
+	hooks.calls = append(hooks.calls, strings.Repeat("  ", hooks.depth)+"parameter "+name+" "+formatLiteral(value))
+}
+
+func (hooks *recordingHooks) FunctionCall(name string, duration time.Duration, err error) {
+	hooks.calls = append(hooks.calls, strings.Repeat("  ", hooks.depth)+"function "+name)
+}
+
+func (hooks *recordingHooks) Error(err error) {
+	hooks.calls = append(hooks.calls, "error "+err.Error())
+}
+
+func TestEvaluationHooks(test *testing.T) {
+
+	hooks := new(recordingHooks)
+
+	env := NewEnv()
+	env.Hooks = hooks
+	env.Functions = map[string]ExpressionFunction{
+		"double": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0].(float64) * 2, nil
+		},
+	}
+
+	expression, err := env.Compile("double(a) > 2 || b")
+	if err != nil {
+		test.Logf("Failed to compile expression: %v", err)
+		test.FailNow()
+	}
+
+	result, err := expression.Evaluate(map[string]interface{}{"a": 3, "b": false})
+	if err != nil || result != true {
+		test.Logf("Expected true, got %v (error %v)", result, err)
+		test.FailNow()
+	}
+
+	expected := []string{
+		"enter double(a) > 2 || b",
+		"  enter double(a) > 2",
+		"    enter double(a)",
+		"      enter a",
+		"        parameter a 3",
+		"      exit  3",
+		"      function double",
+		"    exit () 6",
+		"    enter 2",
+		"    exit  2",
+		"  exit > true",
+		"exit || true",
+	}
+
+	if strings.Join(hooks.calls, "\n") != strings.Join(expected, "\n") {
+		test.Logf("Expected hooks to be called with:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(hooks.calls, "\n"))
+		test.Fail()
+	}
+
+	hooks.calls = nil
+
+	_, err = expression.Evaluate(map[string]interface{}{"a": 1})
+	if err == nil {
+		test.Logf("Expected evaluation to fail without 'b'")
+		test.FailNow()
+	}
+
+	if hooks.calls[len(hooks.calls)-1] != "error "+err.Error() {
+		test.Logf("Expected the error hook to be called last, got %v", hooks.calls)
+		test.Fail()
+	}
+}
+
+func TestEvaluationHooksAreOptional(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("a + 1")
+	hooks := new(recordingHooks)
+
+	// installed on a copy of the expression, so the original is unaffected.
+	hooked := *expression
+	hooked.Hooks = hooks
+
+	expression.Evaluate(map[string]interface{}{"a": 1})
+	if len(hooks.calls) != 0 {
+		test.Logf("Expected hooks not to be called for an expression without them, got %v", hooks.calls)
+		test.Fail()
+	}
+
+	hooked.Evaluate(map[string]interface{}{"a": 1})
+	if len(hooks.calls) == 0 {
+		test.Logf("Expected hooks to be called for an expression with them")
+		test.Fail()
+	}
+}
+
+func TestRuleHooks(test *testing.T) {
+
+	envHooks := new(recordingHooks)
+	ruleHooks := new(recordingHooks)
+
+	env := NewEnv()
+	env.Hooks = envHooks
+
+	rules, err := NewRuleSet(env, []Rule{
+		{Name: "adult", Expression: "age >= 18", Hooks: ruleHooks},
+		{Name: "senior", Expression: "age >= 65"},
+	})
+	if err != nil {
+		test.Logf("Failed to compile rules: %v", err)
+		test.FailNow()
+	}
+
+	rules.Evaluate(map[string]interface{}{"age": 30}, MatchAll)
+
+	for _, call := range ruleHooks.calls {
+		if strings.Contains(call, "65") || strings.Contains(call, "parameter") {
+			test.Logf("Expected rule hooks to only see their own rule, got %v", ruleHooks.calls)
+			test.Fail()
+			break
+		}
+	}
+
+	var lookups int
+	for _, call := range envHooks.calls {
+		if strings.Contains(call, "parameter age") {
+			lookups++
+		}
+	}
+
+	if lookups != 1 {
+		test.Logf("Expected the parameter to be looked up once, got %v", envHooks.calls)
+		test.Fail()
+	}
+}
+
+func TestExpvarHooks(test *testing.T) {
+
+	counters := new(expvar.Map).Init()
+
+	env := NewEnv()
+	env.Hooks = NewExpvarHooks(counters)
+	env.Functions = map[string]ExpressionFunction{
+		"check": func(arguments ...interface{}) (interface{}, error) {
+			if arguments[0] == "bad" {
+				return nil, errors.New("Bad value")
+			}
+			return true, nil
+		},
+	}
+
+	expression, _ := env.Compile("check(a) && a != b")
+
+	expression.Evaluate(map[string]interface{}{"a": "good", "b": "other"})
+	expression.Evaluate(map[string]interface{}{"a": "bad", "b": "other"})
+
+	// [a] is used twice, but only read once per evaluation.
+	expected := map[string]string{
+		"parameters.a":           "2",
+		"parameters.b":           "1",
+		"functions.check.calls":  "2",
+		"functions.check.errors": "1",
+		"errors":                 "1",
+	}
+
+	for key, value := range expected {
+
+		counter := counters.Get(key)
+		if counter == nil || counter.String() != value {
+			test.Logf("Expected counter '%s' to be %s, got %v", key, value, counter)
+			test.Fail()
+		}
+	}
+
+	if counters.Get("functions.check.nanoseconds") == nil {
+		test.Logf("Expected the time spent in functions to be counted")
+		test.Fail()
+	}
+}
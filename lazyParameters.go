@@ -0,0 +1,93 @@
+package govaluate
+
+import (
+	"errors"
+	"sync"
+)
+
+/*
+	LazyParameters are parameters which are only resolved when an expression actually uses them,
+	by calling the function given for each name. Meant for values which are expensive to get, such as those loaded from a database.
+
+	When given to `Eval`, each function is called at most once per evaluation, no matter how many times the parameter is used,
+	and not at all if the expression never reaches it (such as the right side of an `&&` whose left side is false).
+	The same LazyParameters can be used by any number of concurrent evaluations, each of which resolves values separately.
+	If the functions are themselves called concurrently, they must be safe to do so.
+
+	Calling `Get` directly resolves the parameter every time; use `Memoized` to get Parameters which only resolve each value once.
+*/
+type LazyParameters map[string]func() (interface{}, error)
+
+func (p LazyParameters) Get(name string) (interface{}, error) {
+
+	resolve, found := p[name]
+
+	if !found {
+		errorMessage := "No parameter '" + name + "' found."
+		return nil, errors.New(errorMessage)
+	}
+
+	return resolve()
+}
+
+/*
+	Returns Parameters which resolve each of these parameters the first time they're used, and return the same value
+	(or error) every time after that. Safe to use concurrently; a parameter being resolved by one goroutine
+	makes any others which need it wait for the result.
+*/
+func (p LazyParameters) Memoized() Parameters {
+	return newMemoizedParameters(p)
+}
+
+func (p LazyParameters) forEvaluation() Parameters {
+	return p.Memoized()
+}
+
+/*
+	Parameters which need their own state for each evaluation (such as a cache of resolved values) implement this,
+	and `Eval` uses what it returns instead of them.
+*/
+type evaluationScopedParameters interface {
+	forEvaluation() Parameters
+}
+
+/*
+	Parameters which get each value from their parent only once.
+*/
+type memoizedParameters struct {
+	parent Parameters
+	values map[string]*memoizedValue
+	lock   sync.Mutex
+}
+
+type memoizedValue struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+func newMemoizedParameters(parent Parameters) *memoizedParameters {
+	return &memoizedParameters{
+		parent: parent,
+		values: make(map[string]*memoizedValue),
+	}
+}
+
+func (p *memoizedParameters) Get(name string) (interface{}, error) {
+
+	p.lock.Lock()
+
+	memoized, found := p.values[name]
+	if !found {
+		memoized = new(memoizedValue)
+		p.values[name] = memoized
+	}
+
+	p.lock.Unlock()
+
+	// resolved outside of the lock, so that slow parameters don't hold up others.
+	memoized.once.Do(func() {
+		memoized.value, memoized.err = p.parent.Get(name)
+	})
+	return memoized.value, memoized.err
+}
@@ -25,6 +25,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			PATTERN,
 			FUNCTION,
@@ -32,6 +33,7 @@ var validLexerStates = []lexerState{
 			STRING,
 			TIME,
 			CLAUSE,
+			LET,
 		},
 	},
 
@@ -45,6 +47,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			PATTERN,
 			FUNCTION,
@@ -53,6 +56,7 @@ var validLexerStates = []lexerState{
 			TIME,
 			CLAUSE,
 			CLAUSE_CLOSE,
+			LET,
 		},
 	},
 
@@ -67,6 +71,7 @@ var validLexerStates = []lexerState{
 			MODIFIER,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			STRING,
 			PATTERN,
@@ -76,6 +81,7 @@ var validLexerStates = []lexerState{
 			LOGICALOP,
 			TERNARY,
 			SEPARATOR,
+			TERMINATOR,
 		},
 	},
 
@@ -92,6 +98,7 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			TERMINATOR,
 		},
 	},
 	{
@@ -107,6 +114,23 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			TERMINATOR,
+		},
+	},
+	{
+
+		kind:       NULL,
+		isEOF:      true,
+		isNullable: true,
+		validNextKinds: []TokenKind{
+
+			MODIFIER,
+			COMPARATOR,
+			LOGICALOP,
+			CLAUSE_CLOSE,
+			TERNARY,
+			SEPARATOR,
+			TERMINATOR,
 		},
 	},
 	{
@@ -122,6 +146,7 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			TERMINATOR,
 		},
 	},
 	{
@@ -136,6 +161,7 @@ var validLexerStates = []lexerState{
 			LOGICALOP,
 			CLAUSE_CLOSE,
 			SEPARATOR,
+			TERMINATOR,
 		},
 	},
 	{
@@ -150,6 +176,7 @@ var validLexerStates = []lexerState{
 			LOGICALOP,
 			CLAUSE_CLOSE,
 			SEPARATOR,
+			TERMINATOR,
 		},
 	},
 	{
@@ -165,6 +192,8 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			LAMBDA,
+			TERMINATOR,
 		},
 	},
 	{
@@ -181,6 +210,7 @@ var validLexerStates = []lexerState{
 			ACCESSOR,
 			STRING,
 			BOOLEAN,
+			NULL,
 			CLAUSE,
 			CLAUSE_CLOSE,
 		},
@@ -195,6 +225,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			FUNCTION,
 			ACCESSOR,
@@ -215,6 +246,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			FUNCTION,
 			ACCESSOR,
@@ -233,6 +265,7 @@ var validLexerStates = []lexerState{
 
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			VARIABLE,
 			FUNCTION,
 			ACCESSOR,
@@ -251,6 +284,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			STRING,
 			TIME,
 			VARIABLE,
@@ -282,6 +316,66 @@ var validLexerStates = []lexerState{
 			CLAUSE_CLOSE,
 			TERNARY,
 			SEPARATOR,
+			TERMINATOR,
+		},
+	},
+	{
+
+		kind:       LAMBDA,
+		isEOF:      false,
+		isNullable: false,
+		validNextKinds: []TokenKind{
+
+			PREFIX,
+			NUMERIC,
+			BOOLEAN,
+			NULL,
+			STRING,
+			TIME,
+			VARIABLE,
+			FUNCTION,
+			ACCESSOR,
+			CLAUSE,
+		},
+	},
+	{
+
+		kind:       LET,
+		isEOF:      false,
+		isNullable: false,
+		validNextKinds: []TokenKind{
+
+			PREFIX,
+			NUMERIC,
+			BOOLEAN,
+			NULL,
+			STRING,
+			TIME,
+			VARIABLE,
+			FUNCTION,
+			ACCESSOR,
+			CLAUSE,
+		},
+	},
+	{
+
+		kind:       TERMINATOR,
+		isEOF:      false,
+		isNullable: false,
+		validNextKinds: []TokenKind{
+
+			PREFIX,
+			NUMERIC,
+			BOOLEAN,
+			NULL,
+			VARIABLE,
+			PATTERN,
+			FUNCTION,
+			ACCESSOR,
+			STRING,
+			TIME,
+			CLAUSE,
+			LET,
 		},
 	},
 	{
@@ -294,6 +388,7 @@ var validLexerStates = []lexerState{
 			PREFIX,
 			NUMERIC,
 			BOOLEAN,
+			NULL,
 			STRING,
 			TIME,
 			VARIABLE,
@@ -313,7 +408,7 @@ func (s lexerState) canTransitionTo(kind TokenKind) bool {
 	return false
 }
 
-func checkExpressionSyntax(tokens []ExpressionToken) error {
+func checkExpressionSyntax(tokens []ExpressionToken) (int, error) {
 
 	var state lexerState
 	var lastToken ExpressionToken
@@ -321,39 +416,39 @@ func checkExpressionSyntax(tokens []ExpressionToken) error {
 
 	state = validLexerStates[0]
 
-	for _, token := range tokens {
+	for index, token := range tokens {
 
 		if !state.canTransitionTo(token.Kind) {
 
 			// call out a specific error for tokens looking like they want to be functions.
 			if lastToken.Kind == VARIABLE && token.Kind == CLAUSE {
-				return errors.New("Undefined function " + lastToken.Value.(string))
+				return index, errors.New("Undefined function " + lastToken.Value.(string))
 			}
 
 			firstStateName := fmt.Sprintf("%s [%v]", state.kind.String(), lastToken.Value)
 			nextStateName := fmt.Sprintf("%s [%v]", token.Kind.String(), token.Value)
 
-			return errors.New("Cannot transition token types from " + firstStateName + " to " + nextStateName)
+			return index, errors.New("Cannot transition token types from " + firstStateName + " to " + nextStateName)
 		}
 
 		state, err = getLexerStateForToken(token.Kind)
 		if err != nil {
-			return err
+			return index, err
 		}
 
 		if !state.isNullable && token.Value == nil {
 
 			errorMsg := fmt.Sprintf("Token kind '%v' cannot have a nil value", token.Kind.String())
-			return errors.New(errorMsg)
+			return index, errors.New(errorMsg)
 		}
 
 		lastToken = token
 	}
 
 	if !state.isEOF {
-		return errors.New("Unexpected end of expression")
+		return len(tokens), errors.New("Unexpected end of expression")
 	}
-	return nil
+	return 0, nil
 }
 
 func getLexerStateForToken(kind TokenKind) (lexerState, error) {
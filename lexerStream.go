@@ -1,5 +1,10 @@
 package govaluate
 
+import (
+	"fmt"
+	"strings"
+)
+
 type lexerStream struct {
 	source   []rune
 	position int
@@ -27,3 +32,27 @@ func (s *lexerStream) rewind(amount int) {
 func (s lexerStream) canRead() bool {
 	return s.position < s.length
 }
+
+/*
+	Adds the line and column of the given [position] to [err], if the source spans multiple lines.
+	Single-line expressions are short enough that their errors are left alone.
+*/
+func (s lexerStream) locateError(position int, err error) error {
+
+	if !strings.ContainsRune(string(s.source), '\n') {
+		return err
+	}
+
+	line, column := 1, 1
+	for _, character := range s.source[:position] {
+
+		if character == '\n' {
+			line++
+			column = 1
+			continue
+		}
+		column++
+	}
+
+	return fmt.Errorf("%w (line %d, column %d)", err, line, column)
+}
@@ -0,0 +1,116 @@
+package govaluate
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestMatcher(test *testing.T) {
+
+	rules := []Rule{
+		{Name: "us pro", Expression: "country == 'US' && plan in ('pro', 'enterprise') && age > 18"},
+		{Name: "nl", Expression: "'NL' == country"},
+		{Name: "adult", Expression: "age >= 18"},
+		{Name: "minor", Expression: "18 > age"},
+		{Name: "senior", Expression: "(age >= 65 && retired)"},
+		{Name: "trial", Expression: "plan == 'trial' || age < 0"},
+		{Name: "any", Expression: "true"},
+	}
+
+	matcher, err := NewMatcher(nil, rules)
+	if err != nil {
+		test.Logf("Failed to compile matcher: %v", err)
+		test.FailNow()
+	}
+
+	if !reflect.DeepEqual(matcher.unindexed, []int{5, 6}) {
+		test.Logf("Expected only the rules without indexable comparisons to be unindexed, got %v", matcher.unindexed)
+		test.Fail()
+	}
+
+	results, err := matcher.Evaluate(map[string]interface{}{"country": "US", "plan": "pro", "age": 18, "retired": false}, MatchAll)
+	if err != nil {
+		test.Logf("Failed to match: %v", err)
+		test.FailNow()
+	}
+
+	expected := []RuleResult{
+		{Name: "adult", Result: true},
+		{Name: "any", Result: true},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		test.Logf("Expected %v, got %v", expected, results)
+		test.Fail()
+	}
+
+	// with the same rules, the matcher should always agree with evaluating every rule.
+	ruleSet, _ := NewRuleSet(nil, rules)
+	random := rand.New(rand.NewSource(1))
+
+	countries := []interface{}{"US", "NL", "DE", 1}
+	plans := []interface{}{"pro", "enterprise", "trial", "free"}
+
+	for i := 0; i < 1000; i++ {
+
+		parameters := map[string]interface{}{
+			"country": countries[random.Intn(len(countries))],
+			"plan":    plans[random.Intn(len(plans))],
+			"age":     float64(random.Intn(100) - 5),
+			"retired": random.Intn(2) == 0,
+		}
+
+		for _, mode := range []RuleSetMode{MatchAll, MatchFirst} {
+
+			expected, err := ruleSet.Evaluate(parameters, mode)
+			if err != nil {
+				test.Logf("Failed to evaluate rules: %v", err)
+				test.FailNow()
+			}
+
+			actual, err := matcher.Evaluate(parameters, mode)
+			if err != nil || !reflect.DeepEqual(actual, expected) {
+				test.Logf("Expected %v for %v, got %v (error %v)", expected, parameters, actual, err)
+				test.FailNow()
+			}
+		}
+	}
+}
+
+func TestMatcherFailures(test *testing.T) {
+
+	matcher, _ := NewMatcher(nil, []Rule{
+		{Name: "country", Expression: "country == 'US'"},
+		{Name: "age", Expression: "age > 18"},
+	})
+
+	_, err := matcher.Evaluate(map[string]interface{}{"country": "US"}, MatchAll)
+	if err == nil || err.Error() != "Unable to evaluate rule 'age': No parameter 'age' found." {
+		test.Logf("Expected an error for a missing indexed parameter, got %v", err)
+		test.Fail()
+	}
+}
+
+/*
+	Benchmarks matching against many rules, each of which only one or two inputs can match.
+*/
+func BenchmarkMatcher(bench *testing.B) {
+
+	var rules []Rule
+
+	for i := 0; i < 1000; i++ {
+		rules = append(rules, Rule{
+			Name:       fmt.Sprintf("rule %d", i),
+			Expression: fmt.Sprintf("country == 'C%d' && plan in ('pro', 'enterprise') && age > %d", i%200, i%50),
+		})
+	}
+
+	matcher, _ := NewMatcher(nil, rules)
+	parameters := map[string]interface{}{"country": "C17", "plan": "pro", "age": 30}
+
+	bench.ResetTimer()
+	for i := 0; i < bench.N; i++ {
+		_, _ = matcher.Evaluate(parameters, MatchAll)
+	}
+}
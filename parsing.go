@@ -9,46 +9,132 @@ import (
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 )
 
-func parseTokens(expression string, functions map[string]ExpressionFunction) ([]ExpressionToken, error) {
+// separates the parts of an accessor whose value may be nil, such as `foo?.Bar`.
+const optionalAccessor = "?."
+
+/*
+	Lexes the given [expression] into tokens using the functions and options of the given [env], checking that they're balanced and form valid syntax.
+	Also returns the name each FUNCTION token was called by, keyed by the index of the token.
+*/
+func parseTokens(env *Env, expression string) ([]ExpressionToken, map[int]string, error) {
 
 	var ret []ExpressionToken
+	var functionNames map[int]string
 	var token ExpressionToken
 	var stream *lexerStream
 	var state lexerState
 	var err error
 	var found bool
+	var index int
+
+	// the position in the expression at which each token starts, so that errors can say where they happened.
+	var positions []int
 
 	stream = newLexerStream(expression)
 	state = validLexerStates[0]
 
-	for stream.canRead() {
+	for {
+
+		err = skipWhitespaceAndComments(stream)
+		if err != nil {
+			return ret, nil, stream.locateError(stream.position, err)
+		}
 
-		token, found, err = readToken(stream, state, functions)
+		if !stream.canRead() {
+			break
+		}
+
+		position := stream.position
+
+		token, found, err = readToken(stream, state, env)
 		if err != nil {
-			return ret, err
+			return ret, nil, stream.locateError(position, err)
 		} else if !found {
 			break
 		}
 
 		state, err = getLexerStateForToken(token.Kind)
 		if err != nil {
-			return ret, err
+			return ret, nil, err
+		}
+
+		// functions are only known by name in the source, but it's needed to serialize them.
+		if token.Kind == FUNCTION {
+
+			if functionNames == nil {
+				functionNames = make(map[int]string)
+			}
+			functionNames[len(ret)] = string(stream.source[position:stream.position])
 		}
 
 		// append this valid token
 		ret = append(ret, token)
+		positions = append(positions, position)
+	}
+
+	// errors about a missing token are reported at the end of the expression
+	positions = append(positions, stream.length)
+
+	index, err = checkBalance(ret)
+	if err != nil {
+		return nil, nil, stream.locateError(positions[index], err)
 	}
 
-	if err := checkBalance(ret); err != nil {
-		return nil, err
+	index, err = checkExpressionSyntax(ret)
+	if err != nil {
+		return nil, nil, stream.locateError(positions[index], err)
+	}
+	return ret, functionNames, nil
+}
+
+/*
+	Advances the [stream] past any whitespace and comments, both line comments (which start with two slashes) and block comments.
+*/
+func skipWhitespaceAndComments(stream *lexerStream) error {
+
+	var character rune
+
+	for stream.canRead() {
+
+		character = stream.source[stream.position]
+
+		if unicode.IsSpace(character) {
+			stream.position++
+			continue
+		}
+
+		if character != '/' || stream.position+1 >= stream.length {
+			return nil
+		}
+
+		switch stream.source[stream.position+1] {
+
+		case '/':
+			for stream.canRead() && stream.source[stream.position] != '\n' {
+				stream.position++
+			}
+
+		case '*':
+			remaining := string(stream.source[stream.position+2:])
+
+			end := strings.Index(remaining, "*/")
+			if end < 0 {
+				return errors.New("Unclosed block comment")
+			}
+			stream.position += 2 + utf8.RuneCountInString(remaining[:end]) + 2
+
+		default:
+			return nil
+		}
 	}
-	return ret, nil
+	return nil
 }
 
 //nolint: gocognit
-func readToken(stream *lexerStream, state lexerState, functions map[string]ExpressionFunction) (ExpressionToken, bool, error) {
+func readToken(stream *lexerStream, state lexerState, env *Env) (ExpressionToken, bool, error) {
 
 	var function ExpressionFunction
 	var ret ExpressionToken
@@ -80,37 +166,23 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 		// numeric constant
 		if isNumeric(character) {
 
-			if stream.canRead() && character == '0' {
-				character = stream.readCharacter()
-
-				if stream.canRead() && character == 'x' {
-					tokenString, _ = readUntilFalse(stream, false, true, isHexDigit)
-					tokenValueInt, err := strconv.ParseUint(tokenString, 16, 64)
-
-					if err != nil {
-						return ExpressionToken{}, false,
-							fmt.Errorf("Unable to parse hex value '%v' to uint64", tokenString)
-					}
-
-					kind = NUMERIC
-					tokenValue = float64(tokenValueInt)
-					break
-				} else {
-					stream.rewind(1)
-				}
-			}
-
-			tokenString = readTokenUntilFalse(stream, isNumeric)
-			tokenValue, err = strconv.ParseFloat(tokenString, 64)
-
+			tokenValue, err = readNumber(stream)
 			if err != nil {
-				return ExpressionToken{}, false,
-					fmt.Errorf("Unable to parse numeric value '%v' to float64", tokenString)
+				return ExpressionToken{}, false, err
 			}
+
 			kind = NUMERIC
 			break
 		}
 
+		// semicolon, ending the value of a let binding
+		if character == ';' {
+
+			tokenValue = ";"
+			kind = TERMINATOR
+			break
+		}
+
 		// comma, separator
 		if character == ',' {
 
@@ -131,6 +203,15 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 
 			// above method normally rewinds us to the closing bracket, which we want to skip.
 			stream.rewind(-1)
+
+			// constant?
+			constantKind, constantValue, found, err := env.findConstant(tokenValue.(string))
+			if err != nil {
+				return ExpressionToken{}, false, err
+			}
+			if found {
+				kind, tokenValue = constantKind, constantValue
+			}
 			break
 		}
 
@@ -139,8 +220,31 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 
 			tokenString = readTokenUntilFalse(stream, isVariableName)
 
+			// optional chaining, which can't be part of a variable name (since '?' is also the ternary operator)
+			for isOptionalAccessor(stream) {
+
+				stream.position += len(optionalAccessor)
+				tokenValue, _ = readUntilFalse(stream, false, true, isVariableName)
+				tokenString += optionalAccessor + tokenValue.(string)
+			}
+
 			kind, tokenValue = VARIABLE, tokenString
 
+			// let binding?
+			if tokenString == "let" {
+
+				tokenString, found = readBindingName(stream)
+				if found {
+
+					if _, isConstant := env.Constants[tokenString]; isConstant {
+						return ExpressionToken{}, false, fmt.Errorf("Unable to bind '%s', it is a constant", tokenString)
+					}
+
+					kind, tokenValue = LET, tokenString
+					break
+				}
+			}
+
 			// boolean?
 			if tokenValue == "true" {
 				kind, tokenValue = BOOLEAN, true
@@ -148,6 +252,11 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 				kind, tokenValue = BOOLEAN, false
 			}
 
+			// null?
+			if tokenValue == "null" {
+				kind, tokenValue = NULL, nil
+			}
+
 			// textual operator?
 			if tokenValue == "in" || tokenValue == "IN" {
 
@@ -155,12 +264,31 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 				kind, tokenValue = COMPARATOR, "in"
 			}
 
-			// function?
-			function, found = functions[tokenString]
+			// function? builtins only count when they're actually called, so that parameters may share their names.
+			function, found = env.Functions[tokenString]
+			if !found && isFollowedByClause(stream) {
+				function, found = builtinFunctions[tokenString]
+			}
 			if found {
 				kind, tokenValue = FUNCTION, function
 			}
 
+			// constant? like a let binding, a lambda can't be given the name of one.
+			if kind == VARIABLE {
+
+				if _, isConstant := env.Constants[tokenString]; isConstant && isFollowedByLambda(stream) {
+					return ExpressionToken{}, false, fmt.Errorf("Unable to use '%s' as a lambda parameter, it is a constant", tokenString)
+				}
+
+				constantKind, constantValue, found, err := env.findConstant(tokenString)
+				if err != nil {
+					return ExpressionToken{}, false, err
+				}
+				if found {
+					kind, tokenValue = constantKind, constantValue
+				}
+			}
+
 			// accessor?
 			accessorIndex := strings.Index(tokenString, ".")
 			if accessorIndex > 0 {
@@ -171,13 +299,13 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 				}
 
 				kind = ACCESSOR
-				splits := strings.Split(tokenString, ".")
+				splits := splitAccessor(tokenString)
 				tokenValue = splits
 
 				// check that none of them are unexported
 				for i := 1; i < len(splits); i++ {
 
-					firstCharacter := getFirstRune(splits[i])
+					firstCharacter := getFirstRune(strings.TrimPrefix(splits[i], "?"))
 
 					if unicode.ToUpper(firstCharacter) != firstCharacter {
 						return ExpressionToken{}, false,
@@ -188,23 +316,33 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			break
 		}
 
-		if !isNotQuote(character) {
-			tokenValue, completed = readUntilFalse(stream, true, false, isNotQuote)
+		// raw string, which is never interpreted as a time, and has no escape sequences (useful for regexes).
+		if character == '`' {
 
-			if !completed {
-				return ExpressionToken{}, false, errors.New("Unclosed string literal")
+			tokenValue, err = readStringLiteral(stream, character)
+			if err != nil {
+				return ExpressionToken{}, false, err
 			}
 
-			// advance the stream one position, since reading until false assumes the terminator is a real token
-			stream.rewind(-1)
+			kind = STRING
+			break
+		}
+
+		if !isNotQuote(character) {
+
+			tokenString, err = readStringLiteral(stream, character)
+			if err != nil {
+				return ExpressionToken{}, false, err
+			}
 
 			// check to see if this can be parsed as a time.
-			tokenTime, found = tryParseTime(tokenValue.(string))
+			tokenTime, found = env.tryParseTime(tokenString)
 			if found {
 				kind = TIME
 				tokenValue = tokenTime
 			} else {
 				kind = STRING
+				tokenValue = tokenString
 			}
 			break
 		}
@@ -222,7 +360,14 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 		}
 
 		// must be a known symbol
+		position := stream.position - 1
 		tokenString = readTokenUntilFalse(stream, isNotAlphanumeric)
+
+		// a comment may directly follow a symbol, as in `a >// comment`
+		if index := indexOfComment(tokenString); index > 0 {
+			tokenString = tokenString[:index]
+			stream.position = position + utf8.RuneCountInString(tokenString)
+		}
 		tokenValue = tokenString
 
 		// quick hack for the case where "-" can mean "prefixed negation" or "minus", which are used
@@ -263,6 +408,13 @@ func readToken(stream *lexerStream, state lexerState, functions map[string]Expre
 			break
 		}
 
+		_, found = lambdaSymbols[tokenString]
+		if found {
+
+			kind = LAMBDA
+			break
+		}
+
 		return ret, false, fmt.Errorf("Invalid token: '%s'", tokenString)
 	}
 
@@ -328,6 +480,264 @@ func readUntilFalse(stream *lexerStream, includeWhitespace, breakWhitespace bool
 	return tokenBuffer.String(), conditioned
 }
 
+/*
+	Reads a numeric literal whose first character has just been read, returning its value.
+	Decimals may have an exponent (`1e6`, `.5e-3`), and integers may be written in hex (`0x1F`), binary (`0b1010`) or octal (`0o755`).
+	Any of them may use underscores to separate digits, like `1_000_000`.
+*/
+func readNumber(stream *lexerStream) (float64, error) {
+
+	var character rune
+
+	start := stream.position - 1
+
+	// radix prefix. A bare `0x` is left to be read as a zero followed by a parameter, as it always has been.
+	if stream.source[start] == '0' && stream.canRead() {
+
+		switch unicode.ToLower(stream.source[stream.position]) {
+		case 'x':
+			if stream.position+1 < stream.length {
+				return readRadixNumber(stream, 16, "hex")
+			}
+		case 'b':
+			return readRadixNumber(stream, 2, "binary")
+		case 'o':
+			return readRadixNumber(stream, 8, "octal")
+		}
+	}
+
+	for stream.canRead() {
+
+		character = stream.source[stream.position]
+		if !isNumeric(character) && character != '_' {
+			break
+		}
+		stream.position++
+	}
+
+	// exponent, which needs at least one digit; a number can't be followed by a parameter, so `2e` is a malformed number, not `2` and `e`.
+	if stream.canRead() && unicode.ToLower(stream.source[stream.position]) == 'e' {
+
+		stream.position++
+		if stream.canRead() && (stream.source[stream.position] == '+' || stream.source[stream.position] == '-') {
+			stream.position++
+		}
+
+		if !stream.canRead() || !unicode.IsDigit(stream.source[stream.position]) {
+			return 0, fmt.Errorf("Unable to parse numeric value '%v': no digits follow its exponent", string(stream.source[start:stream.position]))
+		}
+
+		for stream.canRead() && (unicode.IsDigit(stream.source[stream.position]) || stream.source[stream.position] == '_') {
+			stream.position++
+		}
+	}
+
+	tokenString := string(stream.source[start:stream.position])
+
+	value, err := strconv.ParseFloat(tokenString, 64)
+	if err != nil {
+
+		reason := "invalid syntax"
+
+		if strings.Count(tokenString, ".") > 1 {
+			reason = "more than one decimal point"
+		} else if errors.Is(err, strconv.ErrRange) {
+			reason = "value out of range"
+		} else if strings.Contains(tokenString, "_") {
+			reason = "'_' must separate digits"
+		}
+
+		return 0, fmt.Errorf("Unable to parse numeric value '%v' to float64: %s", tokenString, reason)
+	}
+	return value, nil
+}
+
+/*
+	Reads an integer in the given [base], where the stream is positioned at the letter of its prefix (such as the 'x' in `0x1F`).
+*/
+func readRadixNumber(stream *lexerStream, base int, name string) (float64, error) {
+
+	var character rune
+
+	start := stream.position - 1
+	stream.position++
+
+	for stream.canRead() {
+
+		character = stream.source[stream.position]
+		if !isDigitInBase(character, base) && character != '_' {
+			break
+		}
+		stream.position++
+	}
+
+	tokenString := string(stream.source[start:stream.position])
+
+	if stream.position == start+2 {
+		return 0, fmt.Errorf("Unable to parse %s value '%v': no digits follow its prefix", name, tokenString)
+	}
+
+	// a decimal digit right after a binary or octal number is a typo, not the start of another token.
+	if stream.canRead() && unicode.IsDigit(stream.source[stream.position]) {
+		return 0, fmt.Errorf("Unable to parse %s value '%v%c': '%c' is not a valid %s digit",
+			name, tokenString, stream.source[stream.position], stream.source[stream.position], name)
+	}
+
+	value, err := strconv.ParseUint(tokenString, 0, 64)
+	if err != nil {
+
+		reason := "invalid syntax"
+
+		if errors.Is(err, strconv.ErrRange) {
+			reason = "value out of range"
+		} else if strings.Contains(tokenString, "_") {
+			reason = "'_' must separate digits"
+		}
+
+		return 0, fmt.Errorf("Unable to parse %s value '%v' to uint64: %s", name, tokenString, reason)
+	}
+	return float64(value), nil
+}
+
+/*
+	Reads the rest of a string literal which was opened by the given [quote], returning its contents.
+	A string only ends at the same kind of quote that opened it, so `"it's"` and `'say "hi"'` need no escaping.
+	Quoted strings support the same escape sequences as Go (such as `\n`, `\t`, `\u00e9`); raw (backtick) strings do not.
+*/
+func readStringLiteral(stream *lexerStream, quote rune) (string, error) {
+
+	var buffer bytes.Buffer
+	var character rune
+
+	for stream.canRead() {
+
+		character = stream.readCharacter()
+
+		if character == quote {
+			return buffer.String(), nil
+		}
+
+		if character != '\\' || quote == '`' {
+			buffer.WriteRune(character)
+			continue
+		}
+
+		if !stream.canRead() {
+			break
+		}
+
+		// either quote can be escaped in either kind of string, which is what backslashes used to do for every character.
+		character = stream.source[stream.position]
+		if character == '\'' || character == '"' {
+			stream.position++
+			buffer.WriteRune(character)
+			continue
+		}
+
+		// the longest escape sequence is ten characters, `\U0010ffff`
+		start := stream.position - 1
+		end := start + 10
+		if end > stream.length {
+			end = stream.length
+		}
+
+		value, multibyte, tail, err := strconv.UnquoteChar(string(stream.source[start:end]), byte(quote))
+		if err != nil {
+			return "", fmt.Errorf("Invalid escape sequence '\\%c' in string literal", character)
+		}
+
+		stream.position = end - utf8.RuneCountInString(tail)
+
+		if multibyte {
+			buffer.WriteRune(value)
+		} else {
+			buffer.WriteByte(byte(value))
+		}
+	}
+
+	return "", errors.New("Unclosed string literal")
+}
+
+/*
+	Reads the `name =` which follows the `let` keyword, returning the name.
+	If that isn't what follows, returns false and leaves the stream where it was, so that `let` can still be used as a parameter name.
+*/
+func readBindingName(stream *lexerStream) (string, bool) {
+
+	var name string
+
+	source := stream.source
+	position := stream.position
+
+	for position < stream.length && unicode.IsSpace(source[position]) {
+		position++
+	}
+
+	if position >= stream.length || !unicode.IsLetter(source[position]) {
+		return "", false
+	}
+
+	start := position
+	for position < stream.length && isBindingName(source[position]) {
+		position++
+	}
+	name = string(source[start:position])
+
+	for position < stream.length && unicode.IsSpace(source[position]) {
+		position++
+	}
+
+	// must be a single '=', not the start of '==', '=~' or '=>'
+	if position >= stream.length || source[position] != '=' {
+		return "", false
+	}
+
+	position++
+	if position < stream.length && (source[position] == '=' || source[position] == '~' || source[position] == '>') {
+		return "", false
+	}
+
+	stream.position = position
+	return name, true
+}
+
+/*
+	Returns true if the stream is positioned at a `?.` which is immediately followed by the name of a field or method.
+	Anything else is left to be read as a symbol, such as the ternary or null coalescence operators.
+*/
+func isOptionalAccessor(stream *lexerStream) bool {
+
+	position := stream.position + len(optionalAccessor)
+
+	if position >= stream.length {
+		return false
+	}
+	return string(stream.source[stream.position:position]) == optionalAccessor && unicode.IsLetter(stream.source[position])
+}
+
+/*
+	Splits an accessor such as `foo.Bar?.Baz` into its parts.
+	A part which may be skipped if the value before it is nil (because it was accessed with `?.`) is prefixed with a '?',
+	so the example becomes `["foo", "Bar", "?Baz"]`.
+*/
+func splitAccessor(accessor string) []string {
+
+	var optional bool
+
+	splits := strings.Split(accessor, ".")
+
+	for i, split := range splits {
+
+		if optional {
+			split = "?" + split
+		}
+
+		optional = strings.HasSuffix(split, "?")
+		splits[i] = strings.TrimSuffix(split, "?")
+	}
+	return splits
+}
+
 /*
 	Checks to see if any optimizations can be performed on the given [tokens], which form a complete, valid expression.
 	The returns slice will represent the optimized (or unmodified) list of tokens to use.
@@ -369,12 +779,30 @@ func optimizeTokens(tokens []ExpressionToken) ([]ExpressionToken, error) {
 }
 
 /*
-	Checks the balance of tokens which have multiple parts, such as parenthesis.
+	Returns the index at which a comment starts within the given symbol, or -1 if there isn't one.
 */
-func checkBalance(tokens []ExpressionToken) error {
+func indexOfComment(symbol string) int {
+
+	line := strings.Index(symbol, "//")
+	block := strings.Index(symbol, "/*")
+
+	if line < 0 || (block >= 0 && block < line) {
+		return block
+	}
+	return line
+}
+
+/*
+	Checks the balance of tokens which have multiple parts, such as parenthesis, or let bindings and their terminators.
+	Returns the index of the token at which the imbalance was found (which is the length of [tokens] if it's at the end).
+*/
+func checkBalance(tokens []ExpressionToken) (int, error) {
 	var token ExpressionToken
 	var parens int
 
+	// the parenthesis depth of each let binding whose value hasn't yet been terminated
+	var bindings []int
+
 	stream := newTokenStream(tokens)
 	for stream.hasNext() {
 
@@ -384,15 +812,59 @@ func checkBalance(tokens []ExpressionToken) error {
 			continue
 		}
 		if token.Kind == CLAUSE_CLOSE {
+			if len(bindings) > 0 && bindings[len(bindings)-1] == parens {
+				return stream.index - 1, errors.New("Unterminated let binding")
+			}
 			parens--
 			continue
 		}
+		if token.Kind == LET {
+			bindings = append(bindings, parens)
+			continue
+		}
+		if token.Kind == TERMINATOR {
+			if len(bindings) == 0 || bindings[len(bindings)-1] != parens {
+				return stream.index - 1, errors.New("Unexpected ';' outside of a let binding")
+			}
+			bindings = bindings[:len(bindings)-1]
+			continue
+		}
 	}
 
 	if parens != 0 {
-		return errors.New("Unbalanced parenthesis")
+		return len(tokens), errors.New("Unbalanced parenthesis")
 	}
-	return nil
+	if len(bindings) > 0 {
+		return len(tokens), errors.New("Unterminated let binding")
+	}
+	return 0, nil
+}
+
+/*
+	Returns true if the next non-whitespace character in the [stream] opens a clause.
+	Does not advance the stream.
+*/
+func isFollowedByClause(stream *lexerStream) bool {
+
+	for i := stream.position; i < stream.length; i++ {
+		if !unicode.IsSpace(stream.source[i]) {
+			return stream.source[i] == '('
+		}
+	}
+	return false
+}
+
+/*
+	Returns true if the next token is `=>`, as it is after the parameter of a lambda.
+*/
+func isFollowedByLambda(stream *lexerStream) bool {
+
+	for i := stream.position; i < stream.length; i++ {
+		if !unicode.IsSpace(stream.source[i]) {
+			return i+1 < stream.length && stream.source[i] == '=' && stream.source[i+1] == '>'
+		}
+	}
+	return false
 }
 
 func isHexDigit(character rune) bool {
@@ -406,12 +878,23 @@ func isHexDigit(character rune) bool {
 		character == 'f'
 }
 
+func isDigitInBase(character rune, base int) bool {
+
+	switch base {
+	case 2:
+		return character == '0' || character == '1'
+	case 8:
+		return character >= '0' && character <= '7'
+	}
+	return isHexDigit(character)
+}
+
 func isNumeric(character rune) bool {
 	return unicode.IsDigit(character) || character == '.'
 }
 
 func isNotQuote(character rune) bool {
-	return character != '\'' && character != '"'
+	return character != '\'' && character != '"' && character != '`'
 }
 
 func isNotAlphanumeric(character rune) bool {
@@ -433,55 +916,16 @@ func isVariableName(character rune) bool {
 		character == '.'
 }
 
-func isNotClosingBracket(character rune) bool {
+func isBindingName(character rune) bool {
 
-	return character != ']'
-}
-
-/*
-	Attempts to parse the [candidate] as a Time.
-	Tries a series of standardized date formats, returns the Time if one applies,
-	otherwise returns false through the second return.
-*/
-func tryParseTime(candidate string) (time.Time, bool) {
-
-	var ret time.Time
-	var found bool
-
-	timeFormats := [...]string{
-		time.ANSIC,
-		time.UnixDate,
-		time.RubyDate,
-		time.Kitchen,
-		time.RFC3339,
-		time.RFC3339Nano,
-		"2006-01-02",                         // RFC 3339
-		"2006-01-02 15:04",                   // RFC 3339 with minutes
-		"2006-01-02 15:04:05",                // RFC 3339 with seconds
-		"2006-01-02 15:04:05-07:00",          // RFC 3339 with seconds and timezone
-		"2006-01-02T15Z0700",                 // ISO8601 with hour
-		"2006-01-02T15:04Z0700",              // ISO8601 with minutes
-		"2006-01-02T15:04:05Z0700",           // ISO8601 with seconds
-		"2006-01-02T15:04:05.999999999Z0700", // ISO8601 with nanoseconds
-	}
-
-	for _, format := range timeFormats {
-		ret, found = tryParseExactTime(candidate, format)
-		if found {
-			return ret, true
-		}
-	}
-
-	return time.Now(), false
+	return unicode.IsLetter(character) ||
+		unicode.IsDigit(character) ||
+		character == '_'
 }
 
-func tryParseExactTime(candidate, format string) (time.Time, bool) {
-	ret, err := time.ParseInLocation(format, candidate, time.Local)
-	if err != nil {
-		return time.Now(), false
-	}
+func isNotClosingBracket(character rune) bool {
 
-	return ret, true
+	return character != ']'
 }
 
 func getFirstRune(candidate string) rune {
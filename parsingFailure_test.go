@@ -13,6 +13,7 @@ const (
 	INVALID_TOKEN_TRANSITION = "Cannot transition token types"
 	INVALID_TOKEN_KIND       = "Invalid token"
 	UNCLOSED_QUOTES          = "Unclosed string literal"
+	INVALID_ESCAPE           = "Invalid escape sequence"
 	UNCLOSED_BRACKETS        = "Unclosed parameter bracket"
 	UNBALANCED_PARENTHESIS   = "Unbalanced parenthesis"
 	INVALID_NUMERIC          = "Unable to parse numeric value"
@@ -20,6 +21,8 @@ const (
 	HANGING_ACCESSOR         = "Hanging accessor on token"
 	UNEXPORTED_ACCESSOR      = "Unable to access unexported"
 	INVALID_HEX              = "Unable to parse hex value"
+	UNTERMINATED_BINDING     = "Unterminated let binding"
+	UNEXPECTED_TERMINATOR    = "Unexpected ';'"
 )
 
 /*
@@ -149,6 +152,30 @@ func TestParsingFailure(test *testing.T) {
 			Input:    "foo == 'responseTime",
 			Expected: UNCLOSED_QUOTES,
 		},
+		{
+
+			Name:     "Quote closed by the other kind of quote",
+			Input:    "foo == 'responseTime\"",
+			Expected: UNCLOSED_QUOTES,
+		},
+		{
+
+			Name:     "Unclosed raw string",
+			Input:    "foo =~ `[a-z]+",
+			Expected: UNCLOSED_QUOTES,
+		},
+		{
+
+			Name:     "Unknown escape sequence",
+			Input:    "foo == '\\d'",
+			Expected: INVALID_ESCAPE,
+		},
+		{
+
+			Name:     "Incomplete unicode escape",
+			Input:    "foo == '\\u12'",
+			Expected: INVALID_ESCAPE,
+		},
 		{
 
 			Name:     "Constant regex pattern fail to compile",
@@ -186,6 +213,41 @@ func TestParsingFailure(test *testing.T) {
 			Input:    "foo.bar",
 			Expected: UNEXPORTED_ACCESSOR,
 		},
+		{
+			Name:     "Lambda without body",
+			Input:    "any(foo, x =>)",
+			Expected: INVALID_TOKEN_TRANSITION,
+		},
+		{
+			Name:     "Lambda without parameter",
+			Input:    "any(foo, 1 => 1)",
+			Expected: INVALID_TOKEN_TRANSITION,
+		},
+		{
+			Name:     "Let binding without body",
+			Input:    "let a = 1;",
+			Expected: UNEXPECTED_END,
+		},
+		{
+			Name:     "Let binding without terminator",
+			Input:    "let a = 1",
+			Expected: UNTERMINATED_BINDING,
+		},
+		{
+			Name:     "Let binding unterminated within clause",
+			Input:    "(let a = 1) ; a",
+			Expected: UNTERMINATED_BINDING,
+		},
+		{
+			Name:     "Terminator without let binding",
+			Input:    "1; 2",
+			Expected: UNEXPECTED_TERMINATOR,
+		},
+		{
+			Name:     "Let binding as function argument",
+			Input:    "foo, let a = 1; a",
+			Expected: INVALID_TOKEN_TRANSITION,
+		},
 		{
 			Name:     "Incomplete Hex",
 			Input:    "0x",
@@ -206,6 +268,96 @@ func TestParsingFailure(test *testing.T) {
 			Input:    "0x12g1",
 			Expected: INVALID_TOKEN_TRANSITION,
 		},
+		{
+			Name:     "Unclosed block comment",
+			Input:    "1 + 2 /* comment",
+			Expected: "Unclosed block comment",
+		},
+		{
+			Name:     "Invalid token on later line",
+			Input:    "a > 1 &&\n  b @ 2",
+			Expected: "Invalid token: '@' (line 2, column 5)",
+		},
+		{
+			Name:     "Invalid transition on later line",
+			Input:    "a > 1 && // comment\n// another\n  b c",
+			Expected: "Cannot transition token types from VARIABLE [b] to VARIABLE [c] (line 3, column 5)",
+		},
+		{
+			Name:     "Unexpected end on later line",
+			Input:    "a > 1 &&\nb >",
+			Expected: UNEXPECTED_END + " (line 2, column 4)",
+		},
+		{
+			Name:     "Unclosed block comment on later line",
+			Input:    "a > 1\n  /* comment",
+			Expected: "Unclosed block comment (line 2, column 3)",
+		},
+		{
+			Name:     "Multiple decimal points",
+			Input:    "1.2.3",
+			Expected: "more than one decimal point",
+		},
+		{
+			Name:     "Doubled digit separator",
+			Input:    "1__000",
+			Expected: "'_' must separate digits",
+		},
+		{
+			Name:     "Trailing digit separator",
+			Input:    "1000_",
+			Expected: "'_' must separate digits",
+		},
+		{
+			Name:     "Exponent out of range",
+			Input:    "1e400",
+			Expected: "value out of range",
+		},
+		{
+			Name:     "Exponent without digits",
+			Input:    "1e",
+			Expected: "Unable to parse numeric value '1e': no digits follow its exponent",
+		},
+		{
+			Name:     "Signed exponent without digits",
+			Input:    "1e+",
+			Expected: "Unable to parse numeric value '1e+': no digits follow its exponent",
+		},
+		{
+			Name:     "Exponent followed by a parameter",
+			Input:    "2.5E-x",
+			Expected: "Unable to parse numeric value '2.5E-': no digits follow its exponent",
+		},
+		{
+			Name:     "Invalid binary digit",
+			Input:    "0b1021",
+			Expected: "'2' is not a valid binary digit",
+		},
+		{
+			Name:     "Invalid octal digit",
+			Input:    "0o758",
+			Expected: "'8' is not a valid octal digit",
+		},
+		{
+			Name:     "Incomplete binary",
+			Input:    "0b > 0",
+			Expected: "Unable to parse binary value '0b': no digits follow its prefix",
+		},
+		{
+			Name:     "Bare binary prefix",
+			Input:    "0b",
+			Expected: "Unable to parse binary value '0b': no digits follow its prefix",
+		},
+		{
+			Name:     "Bare octal prefix",
+			Input:    "0o",
+			Expected: "Unable to parse octal value '0o': no digits follow its prefix",
+		},
+		{
+			Name:     "Hex out of range",
+			Input:    "0x1_0000_0000_0000_0000",
+			Expected: "value out of range",
+		},
 	}
 
 	runParsingFailureTests(parsingTests, test)
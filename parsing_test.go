@@ -91,6 +91,96 @@ func TestConstantParsing(test *testing.T) {
 				},
 			},
 		},
+		{
+			Name:  "Hex with uppercase prefix",
+			Input: "0X1F",
+			Expected: []ExpressionToken{
+				{
+					Kind:  NUMERIC,
+					Value: 31.0,
+				},
+			},
+		},
+		{
+			Name:  "Binary",
+			Input: "0b1010",
+			Expected: []ExpressionToken{
+				{
+					Kind:  NUMERIC,
+					Value: 10.0,
+				},
+			},
+		},
+		{
+			Name:  "Octal",
+			Input: "0o755",
+			Expected: []ExpressionToken{
+				{
+					Kind:  NUMERIC,
+					Value: 493.0,
+				},
+			},
+		},
+		{
+			Name:  "Scientific notation",
+			Input: "1e6",
+			Expected: []ExpressionToken{
+				{
+					Kind:  NUMERIC,
+					Value: 1000000.0,
+				},
+			},
+		},
+		{
+			Name:  "Scientific notation with negative exponent",
+			Input: ".5e-3",
+			Expected: []ExpressionToken{
+				{
+					Kind:  NUMERIC,
+					Value: 0.0005,
+				},
+			},
+		},
+		{
+			Name:  "Scientific notation with positive exponent",
+			Input: "2.5E+2",
+			Expected: []ExpressionToken{
+				{
+					Kind:  NUMERIC,
+					Value: 250.0,
+				},
+			},
+		},
+		{
+			Name:  "Digit separators",
+			Input: "1_000_000",
+			Expected: []ExpressionToken{
+				{
+					Kind:  NUMERIC,
+					Value: 1000000.0,
+				},
+			},
+		},
+		{
+			Name:  "Digit separators in fraction",
+			Input: "1_000.000_5",
+			Expected: []ExpressionToken{
+				{
+					Kind:  NUMERIC,
+					Value: 1000.0005,
+				},
+			},
+		},
+		{
+			Name:  "Hex with digit separators",
+			Input: "0xFF_FF",
+			Expected: []ExpressionToken{
+				{
+					Kind:  NUMERIC,
+					Value: 65535.0,
+				},
+			},
+		},
 		{
 			Name:  "Single string",
 			Input: "'foo'",
@@ -435,6 +525,33 @@ func TestConstantParsing(test *testing.T) {
 				},
 			},
 		},
+		{
+			Name:  "Optional accessor",
+			Input: "foo?.Bar.Baz?.Qux",
+			Expected: []ExpressionToken{
+				{
+					Kind:  ACCESSOR,
+					Value: []string{"foo", "?Bar", "Baz", "?Qux"},
+				},
+			},
+		},
+		{
+			Name:  "Null literal",
+			Input: "foo == null",
+			Expected: []ExpressionToken{
+				{
+					Kind:  VARIABLE,
+					Value: "foo",
+				},
+				{
+					Kind:  COMPARATOR,
+					Value: "==",
+				},
+				{
+					Kind: NULL,
+				},
+			},
+		},
 	}
 
 	tokenParsingTests = combineWhitespaceExpressions(tokenParsingTests)
@@ -1306,6 +1423,56 @@ func TestEscapedParameters(test *testing.T) {
 				},
 			},
 		},
+		{
+			Name:  "String literal with Go escape sequences",
+			Input: `'tab\there\nnewline é \x41 \\'`,
+			Expected: []ExpressionToken{
+				{
+					Kind:  STRING,
+					Value: "tab\there\nnewline é A \\",
+				},
+			},
+		},
+		{
+			Name:  "Double quoted string containing single quotes",
+			Input: `"it's"`,
+			Expected: []ExpressionToken{
+				{
+					Kind:  STRING,
+					Value: "it's",
+				},
+			},
+		},
+		{
+			Name:  "Single quoted string containing double quotes",
+			Input: `'say "hi"'`,
+			Expected: []ExpressionToken{
+				{
+					Kind:  STRING,
+					Value: "say \"hi\"",
+				},
+			},
+		},
+		{
+			Name:  "Raw string literal",
+			Input: "`\\d+\\.\\d+ 'quoted' \"too\"`",
+			Expected: []ExpressionToken{
+				{
+					Kind:  STRING,
+					Value: `\d+\.\d+ 'quoted' "too"`,
+				},
+			},
+		},
+		{
+			Name:  "Raw string literal is never a time",
+			Input: "`2014-01-02`",
+			Expected: []ExpressionToken{
+				{
+					Kind:  STRING,
+					Value: "2014-01-02",
+				},
+			},
+		},
 	}
 
 	runTokenParsingTest(testCases, test)
@@ -1380,6 +1547,178 @@ func TestTernaryParsing(test *testing.T) {
 	runTokenParsingTest(tokenParsingTests, test)
 }
 
+func TestLambdaParsing(test *testing.T) {
+
+	tokenParsingTests := []TokenParsingTest{
+		{
+			Name:  "Lambda as builtin argument",
+			Input: "any(foo, x => x > 1)",
+			Expected: []ExpressionToken{
+				{
+					Kind: FUNCTION,
+				},
+				{
+					Kind: CLAUSE,
+				},
+				{
+					Kind:  VARIABLE,
+					Value: "foo",
+				},
+				{
+					Kind: SEPARATOR,
+				},
+				{
+					Kind:  VARIABLE,
+					Value: "x",
+				},
+				{
+					Kind:  LAMBDA,
+					Value: "=>",
+				},
+				{
+					Kind:  VARIABLE,
+					Value: "x",
+				},
+				{
+					Kind:  COMPARATOR,
+					Value: ">",
+				},
+				{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+				{
+					Kind: CLAUSE_CLOSE,
+				},
+			},
+		},
+		{
+			Name:  "Builtin name without call",
+			Input: "count > 1",
+			Expected: []ExpressionToken{
+				{
+					Kind:  VARIABLE,
+					Value: "count",
+				},
+				{
+					Kind:  COMPARATOR,
+					Value: ">",
+				},
+				{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+			},
+		},
+	}
+
+	runTokenParsingTest(tokenParsingTests, test)
+}
+
+/*
+	Tests that names bound by lambdas are only excluded from Vars() while they are in scope.
+*/
+func TestLambdaVars(test *testing.T) {
+
+	expression, err := NewEvaluableExpression("x > 1 && any(xs, x => x > y) && count(ys, y => y) > x")
+	if err != nil {
+		test.Logf("failed to parse lambda var test: %v", err)
+		test.FailNow()
+	}
+
+	expected := []string{"x", "xs", "y", "ys", "x"}
+	actual := expression.Vars()
+
+	if !reflect.DeepEqual(actual, expected) {
+		test.Logf("Vars() gave %v, expected %v", actual, expected)
+		test.Fail()
+	}
+}
+
+func TestLetBindingParsing(test *testing.T) {
+
+	tokenParsingTests := []TokenParsingTest{
+		{
+			Name:  "Let binding",
+			Input: "let total = a * b; total > 1",
+			Expected: []ExpressionToken{
+				{
+					Kind:  LET,
+					Value: "total",
+				},
+				{
+					Kind:  VARIABLE,
+					Value: "a",
+				},
+				{
+					Kind:  MODIFIER,
+					Value: "*",
+				},
+				{
+					Kind:  VARIABLE,
+					Value: "b",
+				},
+				{
+					Kind:  TERMINATOR,
+					Value: ";",
+				},
+				{
+					Kind:  VARIABLE,
+					Value: "total",
+				},
+				{
+					Kind:  COMPARATOR,
+					Value: ">",
+				},
+				{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+			},
+		},
+		{
+			Name:  "Let as a parameter name",
+			Input: "let == 1",
+			Expected: []ExpressionToken{
+				{
+					Kind:  VARIABLE,
+					Value: "let",
+				},
+				{
+					Kind:  COMPARATOR,
+					Value: "==",
+				},
+				{
+					Kind:  NUMERIC,
+					Value: 1.0,
+				},
+			},
+		},
+	}
+
+	runTokenParsingTest(tokenParsingTests, test)
+}
+
+/*
+	Tests that let-bound names are excluded from Vars() only after their value, and only within their clause.
+*/
+func TestLetBindingVars(test *testing.T) {
+
+	expression, err := NewEvaluableExpression("let total = total + (let tax = rate; tax); let rate = 1; total * rate * tax")
+	if err != nil {
+		test.Logf("failed to parse let binding var test: %v", err)
+		test.FailNow()
+	}
+
+	expected := []string{"total", "rate", "tax"}
+	actual := expression.Vars()
+
+	if !reflect.DeepEqual(actual, expected) {
+		test.Logf("Vars() gave %v, expected %v", actual, expected)
+		test.Fail()
+	}
+}
+
 /*
 	Tests to make sure that the String() reprsentation of an expression exactly matches what is given to the parse function.
 */
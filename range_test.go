@@ -0,0 +1,197 @@
+package govaluate
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestAnalyzeRange(test *testing.T) {
+
+	inf := math.Inf(1)
+
+	pricing := map[string]Range{
+		"qty":      IntegerRange(0, inf),
+		"price":    NumberRange(0, 1000),
+		"discount": NumberRange(0, 1),
+		"total":    NumberRange(0, 1000),
+		"country":  StringRange("US", "NL"),
+		"vip":      BoolRange(),
+	}
+
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{input: "qty * price * (1 - discount)", expected: "[0, +Inf]"},
+		{input: "price * (1 - discount) - 5", expected: "[-5, 995]"},
+		{input: "qty + 1", expected: "integers in [1, +Inf]"},
+		{input: "qty * 2 - 1", expected: "integers in [-1, +Inf]"},
+		{input: "-discount", expected: "[-1, 0]"},
+		{input: "price / (1 + discount)", expected: "[0, 1000]"},
+		{input: "price / discount", expected: "[-Inf, +Inf]"},
+		{input: "qty % 7", expected: "integers in [0, 6]"},
+		{input: "qty % -7", expected: "integers in [0, 6]"},
+		{input: "(qty - 20) % 3", expected: "integers in [-2, 2]"},
+		{input: "qty % 2.5", expected: "[0, 2.5]"},
+		{input: "price % discount", expected: "[-Inf, +Inf]"},
+		{input: "price % (discount * 0)", expected: "[-Inf, +Inf]"},
+		{input: "price % (discount - 2)", expected: "[0, 2]"},
+		{input: "discount ** 2", expected: "[0, 1]"},
+		{input: "(discount - 1) ** 2", expected: "[0, 1]"},
+		{input: "2 ** qty", expected: "integers in [0, +Inf]"},
+		{input: "~qty", expected: "integers in [-Inf, +Inf]"},
+		{input: "unknown * 2", expected: "[-Inf, +Inf]"},
+
+		// conditions narrow the parameters they compare to literals.
+		{input: "total > 100 ? total * 0.9 : total", expected: "[0, 900]"},
+		{input: "total - 100 > 0 ? total - 100 : 0", expected: "[-100, 900]"},
+		{input: "price - 10 < 0 ? 0 : price - 10", expected: "[-10, 990]"},
+		{input: "price >= 10 ? price - 10 : 0", expected: "[0, 990]"},
+		{input: "qty > 0 && qty < 3 ? qty : 10", expected: "integers in [1, 10]"},
+		{input: "!(qty <= 5) ? qty : 6", expected: "integers in [6, +Inf]"},
+		{input: "total > 100 ? total", expected: "[100, 1000] | null"},
+		{input: "(total > 100 ? total) ?? 0", expected: "[0, 1000]"},
+		{input: "total > 2000 ? 1 : 2", expected: "integers in [2, 2]"},
+		{input: "total > 100 ? null : qty", expected: "integers in [0, +Inf] | null"},
+
+		// booleans.
+		{input: "total < 0", expected: "false"},
+		{input: "total <= 0", expected: "true | false"},
+		{input: "qty * price >= 0", expected: "true"},
+		{input: "total > 100 && total < 50", expected: "false"},
+		{input: "total > 100 || total <= 100", expected: "true"},
+		{input: "country == 'DE'", expected: "false"},
+		{input: "country in ('US', 'NL')", expected: "true"},
+		{input: "country in ('US', 'DE')", expected: "true | false"},
+		{input: "country != 'DE' && vip", expected: "true | false"},
+		{input: "vip && !vip", expected: "false"},
+		{input: "country =~ '^U'", expected: "true | false"},
+		{input: "f(total) > 0", expected: "true | false"},
+
+		// strings and other types.
+		{input: "'code-' + country", expected: "'code-NL' | 'code-US'"},
+		{input: "country + qty", expected: "any string"},
+		{input: "country ?? 'none'", expected: "'NL' | 'US'"},
+		{input: "missing ?? 'none'", expected: "[-Inf, +Inf] | any string | true | false | other"},
+		{input: "let x = qty + 1; x * 2", expected: "integers in [2, +Inf]"},
+		{input: "country - 1", expected: "nothing"},
+	}
+
+	functions := map[string]ExpressionFunction{
+		"f": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0], nil
+		},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpressionWithFunctions(testCase.input, functions)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		analyzed := expression.AnalyzeRange(pricing)
+
+		actual := analyzed.String()
+		if actual != testCase.expected {
+			test.Logf("Test '%s' failed", testCase.input)
+			test.Logf("Expected range %s, actual %s", testCase.expected, actual)
+			test.Fail()
+		}
+
+		// whatever the expression evaluates to with parameters in their ranges should be in the range it was analyzed to.
+		random := rand.New(rand.NewSource(1))
+
+		for i := 0; i < 200; i++ {
+
+			parameters := make(map[string]interface{})
+			for name, parameter := range pricing {
+				parameters[name] = sampleRange(parameter, random)
+			}
+
+			result, err := expression.Evaluate(parameters)
+			if err == nil && !rangeContains(analyzed, result) {
+				test.Logf("Test '%s' failed", testCase.input)
+				test.Logf("Evaluated to %v with %v, which isn't in %s", result, parameters, actual)
+				test.Fail()
+				break
+			}
+		}
+	}
+}
+
+func sampleRange(r Range, random *rand.Rand) interface{} {
+
+	if len(r.Strings) > 0 {
+		return r.Strings[random.Intn(len(r.Strings))]
+	}
+	if !r.Number {
+		return random.Intn(2) == 0
+	}
+
+	min, max := math.Max(r.Min, -1e4), math.Min(r.Max, 1e4)
+
+	switch random.Intn(4) {
+	case 0:
+		return min
+	case 1:
+		return max
+	}
+
+	value := min + random.Float64()*(max-min)
+	if r.Integer {
+		value = math.Round(value)
+	}
+	return value
+}
+
+func rangeContains(r Range, value interface{}) bool {
+
+	switch value := value.(type) {
+	case float64:
+		if math.IsNaN(value) {
+			return r.Number
+		}
+		return r.Number && value >= r.Min && value <= r.Max && (!r.Integer || value == math.Trunc(value))
+	case string:
+		return r.AnyString || containsString(r.Strings, value)
+	case bool:
+		return (value && r.True) || (!value && r.False)
+	case nil:
+		return r.Nil
+	}
+	return r.Other
+}
+
+func TestRanges(test *testing.T) {
+
+	cases := []struct {
+		name     string
+		actual   Range
+		expected string
+	}{
+		{name: "empty", actual: Range{}, expected: "nothing"},
+		{name: "integers", actual: IntegerRange(0.5, 3.5), expected: "integers in [1, 3]"},
+		{name: "no integers", actual: IntegerRange(0.2, 0.8), expected: "nothing"},
+		{name: "strings", actual: StringRange("b", "a", "b"), expected: "'a' | 'b'"},
+		{name: "union", actual: NumberRange(0, 1).Union(IntegerRange(3, 4)).Union(Range{Nil: true}), expected: "[0, 4] | null"},
+		{name: "union of strings", actual: StringRange("a").Union(StringRange()), expected: "any string"},
+		{name: "any", actual: AnyRange(), expected: "[-Inf, +Inf] | any string | true | false | null | other"},
+	}
+
+	for _, testCase := range cases {
+		if testCase.actual.String() != testCase.expected {
+			test.Logf("Test '%s' failed", testCase.name)
+			test.Logf("Expected range %s, actual %s", testCase.expected, testCase.actual.String())
+			test.Fail()
+		}
+	}
+
+	if !(Range{}).IsEmpty() || NumberRange(1, 1).IsEmpty() {
+		test.Logf("Expected only the zero range to be empty")
+		test.Fail()
+	}
+}
@@ -0,0 +1,107 @@
+package govaluate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*
+	Parameters which count how many times each is read.
+*/
+type countingParameters struct {
+	values map[string]interface{}
+	reads  map[string]int
+}
+
+func (p countingParameters) Get(name string) (interface{}, error) {
+	p.reads[name]++
+	return MapParameters(p.values).Get(name)
+}
+
+func TestRuleSet(test *testing.T) {
+
+	rules, err := NewRuleSet(nil, []Rule{
+		{Name: "adult", Expression: "age >= 18"},
+		{Name: "discount", Expression: "age >= 18 && country == 'NL' ? price * 0.9 : null", Priority: 1},
+		{Name: "expensive", Expression: "price * 0.9 > 100"},
+		{Name: "blocked", Expression: "country in ('KP', 'IR')", Priority: 2},
+	})
+	if err != nil {
+		test.Logf("Failed to compile rules: %v", err)
+		test.FailNow()
+	}
+
+	// all three parameters, 'age >= 18', and 'price * 0.9'.
+	if rules.slots != 5 {
+		test.Logf("Expected 5 shared stages, got %d", rules.slots)
+		test.Fail()
+	}
+
+	parameters := countingParameters{
+		values: map[string]interface{}{"age": 30, "country": "NL", "price": 200},
+		reads:  make(map[string]int),
+	}
+
+	results, err := rules.Eval(parameters, MatchAll)
+	if err != nil {
+		test.Logf("Failed to evaluate rules: %v", err)
+		test.FailNow()
+	}
+
+	expected := []RuleResult{
+		{Name: "discount", Result: 180.0},
+		{Name: "adult", Result: true},
+		{Name: "expensive", Result: true},
+	}
+	if !reflect.DeepEqual(results, expected) {
+		test.Logf("Expected %v, got %v", expected, results)
+		test.Fail()
+	}
+
+	for name, reads := range parameters.reads {
+		if reads != 1 {
+			test.Logf("Expected parameter '%s' to be read once, got %d", name, reads)
+			test.Fail()
+		}
+	}
+
+	results, err = rules.Evaluate(map[string]interface{}{"age": 12, "country": "NL", "price": 200}, MatchFirst)
+	expected = []RuleResult{
+		{Name: "expensive", Result: true},
+	}
+	if err != nil || !reflect.DeepEqual(results, expected) {
+		test.Logf("Expected %v from the first match, got %v (error %v)", expected, results, err)
+		test.Fail()
+	}
+}
+
+func TestRuleSetFailures(test *testing.T) {
+
+	_, err := NewRuleSet(nil, []Rule{
+		{Name: "first", Expression: "a > 1"},
+		{Name: "first", Expression: "b > 1"},
+	})
+	if err == nil || err.Error() != "Rule 'first' is defined more than once" {
+		test.Logf("Expected an error for a duplicate rule, got %v", err)
+		test.Fail()
+	}
+
+	_, err = NewRuleSet(nil, []Rule{
+		{Name: "broken", Expression: "a >"},
+	})
+	if err == nil || !strings.HasPrefix(err.Error(), "Unable to compile rule 'broken'") {
+		test.Logf("Expected an error for a rule which doesn't compile, got %v", err)
+		test.Fail()
+	}
+
+	rules, _ := NewRuleSet(nil, []Rule{
+		{Name: "missing", Expression: "a > 1 || b > 1"},
+	})
+
+	_, err = rules.Evaluate(map[string]interface{}{"a": 0}, MatchAll)
+	if err == nil || err.Error() != "Unable to evaluate rule 'missing': No parameter 'b' found." {
+		test.Logf("Expected an error for a missing parameter, got %v", err)
+		test.Fail()
+	}
+}
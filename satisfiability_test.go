@@ -0,0 +1,245 @@
+package govaluate
+
+import (
+	"testing"
+)
+
+func TestNormalForms(test *testing.T) {
+
+	cases := []struct {
+		input string
+		cnf   string
+		dnf   string
+	}{
+		{
+			input: "a > 1 && (b || c == 'x')",
+			cnf:   "a > 1 && (b || c == 'x')",
+			dnf:   "(a > 1 && b) || (a > 1 && c == 'x')",
+		},
+		{
+			input: "!(a && b) || c",
+			cnf:   "!a || !b || c",
+			dnf:   "!a || !b || c",
+		},
+		{
+			input: "(a || b) && (c || d)",
+			cnf:   "(a || b) && (c || d)",
+			dnf:   "(a && c) || (a && d) || (b && c) || (b && d)",
+		},
+		{
+			input: "!(x > 1 && f(y))",
+			cnf:   "!(x > 1) || !f(y)",
+			dnf:   "!(x > 1) || !f(y)",
+		},
+		{
+			input: "a && !a",
+			cnf:   "a && !a",
+			dnf:   "false",
+		},
+		{
+			input: "a || !a",
+			cnf:   "true",
+			dnf:   "a || !a",
+		},
+		{
+			input: "a && (b ? c : d)",
+			cnf:   "a && (b ? c : d)",
+			dnf:   "a && (b ? c : d)",
+		},
+	}
+
+	functions := map[string]ExpressionFunction{
+		"f": func(arguments ...interface{}) (interface{}, error) {
+			return true, nil
+		},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpressionWithFunctions(testCase.input, functions)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		cnf, err := expression.ToCNF()
+		if err != nil || cnf.String() != testCase.cnf {
+			test.Logf("Expected CNF of '%s' to be '%s', got '%s' (error %v)", testCase.input, testCase.cnf, cnf, err)
+			test.Fail()
+		}
+
+		dnf, err := expression.ToDNF()
+		if err != nil || dnf.String() != testCase.dnf {
+			test.Logf("Expected DNF of '%s' to be '%s', got '%s' (error %v)", testCase.input, testCase.dnf, dnf, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestNormalFormLimit(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("(a || b) && (c || d) && (e || f) && (g || h) && (i || j) && (k || l) && (m || n) && (o || p) && (q || r) && (s || t) && (u || v) && (w || x) && (y || z)")
+
+	_, err := expression.ToDNF()
+	if err == nil {
+		test.Logf("Expected an expression with 8192 clauses to be too large to normalize")
+		test.Fail()
+	}
+
+	_, err = expression.ToCNF()
+	if err != nil {
+		test.Logf("Expected the conjunctive form to be small enough, got %v", err)
+		test.Fail()
+	}
+}
+
+func TestSatisfiability(test *testing.T) {
+
+	cases := []struct {
+		input    string
+		expected Satisfiability
+	}{
+		{"x > 5 && x < 3", Unsatisfiable},
+		{"x >= 5 && x <= 5", Satisfiable},
+		{"x > 5 && x <= 5", Unsatisfiable},
+		{"x > 1 && x < 2 && x != 1.5", Satisfiable},
+		{"country == 'US' && country == 'NL'", Unsatisfiable},
+		{"country in ('US', 'NL') && country != 'US' && country != 'NL'", Unsatisfiable},
+		{"country in ('US', 'NL') && !(country in ('NL', 'DE'))", Satisfiable},
+		{"plan == 'pro' && (age < 18 || age > 65) && age > 20 && age < 60", Unsatisfiable},
+		{"active && !active", Unsatisfiable},
+		{"x > 1 || x <= 1", Tautology},
+		{"x != 'a' || x != 'b'", Tautology},
+		{"!(x > 1 && x < 1)", Tautology},
+		{"true", Tautology},
+		{"false", Unsatisfiable},
+		{"f(x) && x > 3 && x < 1", Unsatisfiable},
+		{"f(x) && x > 3", Satisfiable},
+		{"g(x) && x > 3", SatisfiabilityUnknown},
+		{"x > y", Satisfiable},
+		{"x != true && x != false", Satisfiable},
+		{"x == true || x == false", Satisfiable},
+	}
+
+	functions := map[string]ExpressionFunction{
+		"f": func(arguments ...interface{}) (interface{}, error) {
+			return true, nil
+		},
+		"g": func(arguments ...interface{}) (interface{}, error) {
+			return false, nil
+		},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpressionWithFunctions(testCase.input, functions)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.CheckSatisfiability()
+		if err != nil || result.Satisfiability != testCase.expected {
+			test.Logf("Expected '%s' to be %v, got %v (error %v)", testCase.input, testCase.expected, result.Satisfiability, err)
+			test.Fail()
+			continue
+		}
+
+		if testCase.expected != Satisfiable && testCase.expected != Tautology {
+			continue
+		}
+
+		// the assignment found should make the expression true.
+		value, err := expression.Evaluate(result.Assignment)
+		if err != nil || value != true {
+			test.Logf("Expected '%s' to be true for %v, got %v (error %v)", testCase.input, result.Assignment, value, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestImplies(test *testing.T) {
+
+	cases := []struct {
+		a, b     string
+		expected Decision
+	}{
+		{"x > 5", "x > 3", DecisionTrue},
+		{"x > 3", "x > 5", DecisionFalse},
+		{"x >= 5", "x > 5", DecisionFalse},
+		{"x == 5", "x >= 5 && x < 6", DecisionTrue},
+		{"country == 'NL' && age >= 18", "country in ('NL', 'DE')", DecisionTrue},
+		{"country in ('NL', 'DE')", "country == 'NL'", DecisionFalse},
+		{"country != 'US'", "country != 'US' || vip", DecisionTrue},
+		{"a && b", "a || b", DecisionTrue},
+		{"a || b", "a && b", DecisionFalse},
+		{"x > 5 && x < 3", "y == 'anything'", DecisionTrue},
+		{"f(x) && y", "f(x)", DecisionTrue},
+		{"f(x)", "f(x) && y", DecisionFalse},
+		{"g(x)", "x > 1", DecisionUnknown},
+		{"x > 1", "x.Count > 1", DecisionUnknown},
+		{"x != true && x != false", "y > 1", DecisionFalse},
+	}
+
+	functions := map[string]ExpressionFunction{
+		"f": func(arguments ...interface{}) (interface{}, error) {
+			return true, nil
+		},
+		"g": func(arguments ...interface{}) (interface{}, error) {
+			return false, nil
+		},
+	}
+
+	for _, testCase := range cases {
+
+		a, err := NewEvaluableExpressionWithFunctions(testCase.a, functions)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", testCase.a, err)
+			test.Fail()
+			continue
+		}
+
+		b, err := NewEvaluableExpressionWithFunctions(testCase.b, functions)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", testCase.b, err)
+			test.Fail()
+			continue
+		}
+
+		actual, err := Implies(a, b)
+		if err != nil || actual != testCase.expected {
+			test.Logf("Expected '%s' implies '%s' to be %v, got %v (error %v)", testCase.a, testCase.b, testCase.expected, actual, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestEquivalent(test *testing.T) {
+
+	cases := []struct {
+		a, b     string
+		expected Decision
+	}{
+		{"!(a && b)", "!a || !b", DecisionTrue},
+		{"x >= 1 && x <= 1", "x == 1", DecisionTrue},
+		{"country in ('NL', 'DE')", "country == 'DE' || country == 'NL'", DecisionTrue},
+		{"a && (b || c)", "(a && b) || (a && c)", DecisionTrue},
+		{"x > 1", "x >= 1", DecisionFalse},
+		{"x > 1", "!(x <= 1)", DecisionTrue},
+		{"x.Count > 1", "x.Count > 1 && x.Count > 0", DecisionUnknown},
+	}
+
+	for _, testCase := range cases {
+
+		a, _ := NewEvaluableExpression(testCase.a)
+		b, _ := NewEvaluableExpression(testCase.b)
+
+		actual, err := Equivalent(a, b)
+		if err != nil || actual != testCase.expected {
+			test.Logf("Expected '%s' equivalent to '%s' to be %v, got %v (error %v)", testCase.a, testCase.b, testCase.expected, actual, err)
+			test.Fail()
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package govaluate
+
+// scopedParameters is a wrapper for Parameters that binds one additional name,
+// such as the parameter of a lambda. All other names are looked up in the enclosing parameters.
+type scopedParameters struct {
+	name   string
+	value  interface{}
+	parent Parameters
+}
+
+func (p scopedParameters) Get(key string) (interface{}, error) {
+	if key == p.name {
+		return p.value, nil
+	}
+
+	return p.parent.Get(key)
+}
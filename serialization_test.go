@@ -0,0 +1,287 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+/*
+	Represents a test of serializing an expression, and evaluating the deserialized form.
+*/
+type SerializationTest struct {
+	Name       string
+	Input      string
+	Functions  map[string]ExpressionFunction
+	Parameters map[string]interface{}
+	Expected   interface{}
+}
+
+func TestSerializationRoundTrip(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"double": func(arguments ...interface{}) (interface{}, error) {
+			return arguments[0].(float64) * 2, nil
+		},
+	}
+
+	testCases := []SerializationTest{
+		{
+			Name:     "Arithmetic",
+			Input:    "(1 + 2) * 3 ** 2 % 5",
+			Expected: 2.0,
+		},
+		{
+			Name:       "Variables and comparators",
+			Input:      "[escaped name] > 1 && foo != 'bar' || !true",
+			Parameters: map[string]interface{}{"escaped name": 2, "foo": "baz"},
+			Expected:   true,
+		},
+		{
+			Name:       "Precompiled pattern",
+			Input:      "foo =~ '^b.r$' && foo !~ `^\\d+$`",
+			Parameters: map[string]interface{}{"foo": "bar"},
+			Expected:   true,
+		},
+		{
+			Name:     "Dates",
+			Input:    "'2014-01-02' < '2014-01-03T10:00:00Z'",
+			Expected: true,
+		},
+		{
+			Name:       "Ternaries, null and coalescence",
+			Input:      "foo == null ? bar ?? 'none' : 'some'",
+			Parameters: map[string]interface{}{"foo": nil, "bar": nil},
+			Expected:   "none",
+		},
+		{
+			Name:       "Accessors",
+			Input:      "foo.Nested.Funk + (foo.NestedPtr?.Funk ?? '!')",
+			Parameters: map[string]interface{}{"foo": dummyParameterInstance},
+			Expected:   "funkalicious!",
+		},
+		{
+			Name:      "Functions",
+			Input:     "double(2) + double(3)",
+			Functions: functions,
+			Expected:  10.0,
+		},
+		{
+			Name:     "Builtins, lambdas and let bindings",
+			Input:    "let limit = 1; count((1, 2, 3) , x => x > limit) in (2, 3)",
+			Expected: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+
+		expression, err := NewEvaluableExpressionWithFunctions(testCase.Input, testCase.Functions)
+		if err != nil {
+			test.Logf("Test '%s' failed to parse: %v", testCase.Name, err)
+			test.Fail()
+			continue
+		}
+
+		resolver := func(name string) (ExpressionFunction, bool) {
+			function, found := testCase.Functions[name]
+			return function, found
+		}
+
+		binary, err := expression.MarshalBinary()
+		if err != nil {
+			test.Logf("Test '%s' failed to marshal binary: %v", testCase.Name, err)
+			test.Fail()
+			continue
+		}
+
+		fromBinary, err := UnmarshalEvaluableExpression(binary, resolver)
+		if err != nil {
+			test.Logf("Test '%s' failed to unmarshal binary: %v", testCase.Name, err)
+			test.Fail()
+			continue
+		}
+
+		encoded, err := json.Marshal(expression)
+		if err != nil {
+			test.Logf("Test '%s' failed to marshal JSON: %v", testCase.Name, err)
+			test.Fail()
+			continue
+		}
+
+		fromJSON, err := UnmarshalEvaluableExpressionJSON(encoded, resolver)
+		if err != nil {
+			test.Logf("Test '%s' failed to unmarshal JSON: %v", testCase.Name, err)
+			test.Fail()
+			continue
+		}
+
+		for _, loaded := range []*EvaluableExpression{fromBinary, fromJSON} {
+
+			if loaded.String() != testCase.Input {
+				test.Logf("Test '%s' lost its expression, got '%s'", testCase.Name, loaded.String())
+				test.Fail()
+			}
+
+			result, err := loaded.Evaluate(testCase.Parameters)
+			if err != nil {
+				test.Logf("Test '%s' failed to evaluate after loading: %v", testCase.Name, err)
+				test.Fail()
+				continue
+			}
+
+			if result != testCase.Expected {
+				test.Logf("Test '%s' evaluated to '%v' after loading, expected '%v'", testCase.Name, result, testCase.Expected)
+				test.Fail()
+			}
+		}
+	}
+}
+
+func TestSerializationInterfaces(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("sum(1, 2) > 2")
+	expression.ChecksTypes = false
+	expression.QueryDateFormat = "2006"
+
+	// as a field of a larger structure, using only the standard interfaces.
+	type rule struct {
+		Condition *EvaluableExpression
+	}
+
+	encoded, err := json.Marshal(rule{Condition: expression})
+	if err != nil {
+		test.Logf("Failed to marshal rule: %v", err)
+		test.FailNow()
+	}
+
+	var loaded rule
+	err = json.Unmarshal(encoded, &loaded)
+	if err != nil {
+		test.Logf("Failed to unmarshal rule: %v", err)
+		test.FailNow()
+	}
+
+	if loaded.Condition.ChecksTypes || loaded.Condition.QueryDateFormat != "2006" {
+		test.Logf("Options were not kept: %+v", loaded.Condition)
+		test.Fail()
+	}
+
+	binary, _ := expression.MarshalBinary()
+
+	var fromBinary EvaluableExpression
+	err = fromBinary.UnmarshalBinary(binary)
+	if err != nil {
+		test.Logf("Failed to unmarshal binary: %v", err)
+		test.FailNow()
+	}
+
+	result, err := fromBinary.Evaluate(nil)
+	if err != nil || result != true {
+		test.Logf("Unmarshalled expression gave '%v', %v", result, err)
+		test.Fail()
+	}
+}
+
+func TestSerializationFailures(test *testing.T) {
+
+	functions := map[string]ExpressionFunction{
+		"foo": func(arguments ...interface{}) (interface{}, error) {
+			return true, nil
+		},
+	}
+
+	// functions can't be found without a resolver
+	expression, _ := NewEvaluableExpressionWithFunctions("foo()", functions)
+	binary, _ := expression.MarshalBinary()
+
+	_, err := UnmarshalEvaluableExpression(binary, nil)
+	if err == nil || !strings.Contains(err.Error(), "Undefined function foo") {
+		test.Logf("Expected undefined function, got %v", err)
+		test.Fail()
+	}
+
+	// functions created from tokens have no name
+	expression, _ = NewEvaluableExpressionFromTokens([]ExpressionToken{
+		{Kind: FUNCTION, Value: functions["foo"]},
+		{Kind: CLAUSE},
+		{Kind: CLAUSE_CLOSE},
+	})
+
+	_, err = expression.MarshalBinary()
+	if err == nil || !strings.Contains(err.Error(), "its name is unknown") {
+		test.Logf("Expected unknown function name, got %v", err)
+		test.Fail()
+	}
+
+	// other versions can't be read
+	_, err = UnmarshalEvaluableExpressionJSON([]byte(`{"version": 99, "tokens": []}`), nil)
+	if err == nil || !strings.Contains(err.Error(), "version 99") {
+		test.Logf("Expected unsupported version, got %v", err)
+		test.Fail()
+	}
+
+	_, err = UnmarshalEvaluableExpressionJSON([]byte(`{"version": 1, "tokens": [{"kind": "NOPE"}]}`), nil)
+	if err == nil || !strings.Contains(err.Error(), "unknown kind 'NOPE'") {
+		test.Logf("Expected unknown kind, got %v", err)
+		test.Fail()
+	}
+
+	_, err = UnmarshalEvaluableExpression([]byte("garbage"), nil)
+	if err == nil {
+		test.Logf("Expected garbage to fail to unmarshal")
+		test.Fail()
+	}
+}
+
+/*
+	Tokens which can't have been produced by parsing must be rejected when deserialized,
+	rather than being evaluated as something else or panicking.
+*/
+func TestSerializationInvalidTokens(test *testing.T) {
+
+	invalidTokens := []struct {
+		token    string
+		expected string
+	}{
+		{`{"kind": "PREFIX", "text": "@"}`, "PREFIX token 0, '@' is not a valid symbol"},
+		{`{"kind": "COMPARATOR", "text": "=<"}`, "COMPARATOR token 0, '=<' is not a valid symbol"},
+		{`{"kind": "LOGICALOP", "text": "&"}`, "LOGICALOP token 0, '&' is not a valid symbol"},
+		{`{"kind": "MODIFIER", "text": "@@"}`, "MODIFIER token 0, '@@' is not a valid symbol"},
+		{`{"kind": "TERNARY", "text": "?:"}`, "TERNARY token 0, '?:' is not a valid symbol"},
+		{`{"kind": "SEPARATOR", "text": ";"}`, "SEPARATOR token 0, ';' is not a valid symbol"},
+		{`{"kind": "LAMBDA", "text": "->"}`, "LAMBDA token 0, '->' is not a valid symbol"},
+		{`{"kind": "ACCESSOR"}`, "ACCESSOR token 0, it has no parts"},
+		{`{"kind": "ACCESSOR", "parts": []}`, "ACCESSOR token 0, it has no parts"},
+	}
+
+	for _, invalid := range invalidTokens {
+
+		_, err := UnmarshalEvaluableExpressionJSON([]byte(`{"version": 1, "tokens": [`+invalid.token+`]}`), nil)
+		if err == nil || !strings.Contains(err.Error(), invalid.expected) {
+			test.Logf("Expected '%s' to fail with '%s', got %v", invalid.token, invalid.expected, err)
+			test.Fail()
+		}
+	}
+
+	// replacing the text of each token in turn must never panic, whether or not the result is still valid.
+	expression, _ := NewEvaluableExpression("!(foo.Bar >= 2 ** -x ? [y] % 3 : (z => z + 1)(4)) || 'a' =~ 'b'")
+	encoded, _ := expression.MarshalJSON()
+
+	var serialized serializedExpression
+	json.Unmarshal(encoded, &serialized)
+
+	for i := range serialized.Tokens {
+		for _, text := range []string{"", "@@", "?", "**", "=>", ",", "in"} {
+
+			mutated := serialized
+			mutated.Tokens = append([]serializedToken{}, serialized.Tokens...)
+			mutated.Tokens[i].Text = text
+			mutated.Tokens[i].Parts = nil
+
+			loaded, err := mutated.deserialize(nil)
+			if err == nil {
+				loaded.Evaluate(map[string]interface{}{"foo": map[string]interface{}{"Bar": 1.0}, "x": 1.0, "y": 2.0, "z": 3.0})
+			}
+		}
+	}
+}
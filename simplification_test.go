@@ -0,0 +1,153 @@
+package govaluate
+
+import (
+	"testing"
+)
+
+func TestStageSimplification(test *testing.T) {
+
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"a > 1 && true", "a > 1"},
+		{"true && a > 1", "a > 1"},
+		{"false && a", "false"},
+		{"a == 1 || false", "a == 1"},
+		{"true || a", "true"},
+		{"!(!(a > 1))", "(a > 1)"},
+		{"!(a == b)", "a != b"},
+		{"!(a =~ 'x')", "a !~ 'x'"},
+		{"-a + -0", "-a"},
+		{"-a - 0", "-a"},
+		{"(a - b) * 1", "(a - b)"},
+		{"-a / 1 - 0", "-a"},
+		{"-1", "-1"},
+		{"!true", "false"},
+		{"true ? a > 1 : b", "a > 1"},
+		{"false ? a : b", "b"},
+		{"true ? a : b", "a"},
+		{"true ? null : a", "null"},
+		{"(true ? a) : b", "a"},
+		{"true ? a", "a"},
+		{"null ?? a", "a"},
+		{"'x' ?? a", "'x'"},
+		{"a in ('x')", "a == 'x'"},
+		{"a in ('x', 'y')", "a in ('x', 'y')"},
+		{"a + 'x' + 'y'", "a + 'xy'"},
+		{"2 * a * 4", "a * 8"},
+		{"(a - 1) * -2 * 2", "(a - 1) * -4"},
+
+		// these could change the result, or whether evaluation fails, depending on the type of a.
+		{"a && true", "a && true"},
+		{"true && a", "true && a"},
+		{"!(!a)", "!(!a)"},
+		{"a * 1", "a * 1"},
+		{"a + 0", "a + 0"},
+		{"-a + 0", "-a + 0"},
+		{"-a - -0", "-a - -0"},
+		{"1 + a + 2", "1 + a + 2"},
+		{"'x' + a + 'y'", "'x' + a + 'y'"},
+		{"a ?? b", "a ?? b"},
+		{"!(a < b)", "!(a < b)"},
+
+		// these could be rounded differently if they were regrouped.
+		{"1 + -a + 2", "1 + -a + 2"},
+		{"2 * (a - 1) * 3", "2 * (a - 1) * 3"},
+		{"a * 0.5 * 4", "a * 0.5 * 4"},
+		{"a + 1 + 2", "a + 1 + 2"},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpression(testCase.input)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		actual := formatStage(expression.evaluationStages)
+		if actual != testCase.expected {
+			test.Logf("Expected '%s' to be simplified to '%s', got '%s'", testCase.input, testCase.expected, actual)
+			test.Fail()
+		}
+	}
+}
+
+func TestStageSimplificationKeepsErrors(test *testing.T) {
+
+	cases := []struct {
+		input    string
+		expected interface{}
+		fails    bool
+	}{
+		{input: "a && true", fails: true},
+		{input: "a * 1", fails: true},
+		{input: "!(!a)", fails: true},
+		{input: "false && a", expected: false},
+		{input: "1 + a + 2", expected: "1x2"},
+		{input: "a + 'y' + 'z'", expected: "xyz"},
+		{input: "a in ('x')", expected: true},
+		{input: "a in 'x'", fails: true},
+		{input: "a in ('y' ?? b)", fails: true},
+		{input: "1 in (1 * 1)", fails: true},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpression(testCase.input)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.Evaluate(map[string]interface{}{"a": "x"})
+
+		if testCase.fails {
+			if err == nil {
+				test.Logf("Expected '%s' to fail, got %v", testCase.input, result)
+				test.Fail()
+			}
+			continue
+		}
+
+		if err != nil || result != testCase.expected {
+			test.Logf("Expected '%s' to be %v, got %v (error %v)", testCase.input, testCase.expected, result, err)
+			test.Fail()
+		}
+	}
+}
+
+func TestStageSimplificationKeepsRounding(test *testing.T) {
+
+	a := 0.3
+
+	cases := []struct {
+		input    string
+		expected float64
+	}{
+		{input: "0.1 + -a + 0.2", expected: 0.1 + -a + 0.2},
+		{input: "0.1 * -a * 3", expected: 0.1 * -a * 3},
+		{input: "a + 1 + 2", expected: a + 1 + 2},
+		{input: "a * 3 * 1e308", expected: a * 3 * 1e308},
+		{input: "a * 2 * 1024", expected: a * 2 * 1024},
+	}
+
+	for _, testCase := range cases {
+
+		expression, err := NewEvaluableExpression(testCase.input)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", testCase.input, err)
+			test.Fail()
+			continue
+		}
+
+		result, err := expression.Evaluate(map[string]interface{}{"a": a})
+		if err != nil || result != testCase.expected {
+			test.Logf("Expected '%s' to be %v, got %v (error %v)", testCase.input, testCase.expected, result, err)
+			test.Fail()
+		}
+	}
+}
@@ -162,6 +162,42 @@ func TestSQLSerialization(test *testing.T) {
 			Input:    "foo ?? bar",
 			Expected: "COALESCE([foo], [bar])",
 		},
+		{
+
+			Name:     "Null equality",
+			Input:    "foo == null",
+			Expected: "[foo] IS NULL",
+		},
+		{
+
+			Name:     "Null inequality",
+			Input:    "foo != null",
+			Expected: "[foo] IS NOT NULL",
+		},
+		{
+
+			Name:     "Null on the left of equality",
+			Input:    "null == foo",
+			Expected: "[foo] IS NULL",
+		},
+		{
+
+			Name:     "Null on the left of inequality",
+			Input:    "null != foo && bar > 1",
+			Expected: "[foo] IS NOT NULL AND [bar] > 1",
+		},
+		{
+
+			Name:     "Null on the left of an expression",
+			Input:    "null == (foo + 1) * 2 ** bar || baz",
+			Expected: "( [foo] + 1 ) * POW(2, [bar]) IS NULL OR [baz]",
+		},
+		{
+
+			Name:     "Null coalesced on the left of equality",
+			Input:    "foo ?? null == bar",
+			Expected: "COALESCE([foo], NULL) = [bar]",
+		},
 		/*
 			// Ternaries don't work yet, because the outputter is not yet sophisticated enough to produce them.
 			QueryTest{
@@ -0,0 +1,150 @@
+package govaluate
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/*
+	Returns the given [stage] written as an expression, which parses back into the same stage.
+	This is the expression as it was planned, so literals which were folded together are written as their result,
+	and dates as the number of seconds they were parsed to.
+*/
+func formatStage(stage *evaluationStage) string {
+
+	if stage == nil {
+		return ""
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, _ := stage.operator(nil, nil, nil)
+		return formatLiteral(value)
+
+	case VALUE:
+		return formatName(stage.reference)
+
+	case NOOP:
+		return "(" + formatStage(stage.rightStage) + ")"
+
+	case ACCESS:
+		accessor := strings.Replace(stage.reference, ".?", optionalAccessor, -1)
+		if stage.rightStage == nil {
+			return accessor
+		}
+		return accessor + formatArguments(stage.rightStage)
+
+	case FUNCTIONAL:
+		name := stage.reference
+		if name == "" {
+			name = "function"
+		}
+		return name + formatArguments(stage.rightStage)
+
+	case SEPARATE:
+		return formatStage(stage.leftStage) + ", " + formatStage(stage.rightStage)
+
+	case NEGATE, INVERT, BITWISE_NOT:
+		return formatSymbol(stage.symbol) + formatStage(stage.rightStage)
+
+	case CLOSURE:
+		return stage.binding + " => " + formatBody(stage.rightStage)
+
+	case BIND:
+		return "let " + stage.binding + " = " + formatStage(stage.leftStage) + "; " + formatBody(stage.rightStage)
+	}
+
+	return formatStage(stage.leftStage) + " " + formatSymbol(stage.symbol) + " " + formatStage(stage.rightStage)
+}
+
+/*
+	Returns the operator which is written for the given [symbol], where it differs from its name.
+*/
+func formatSymbol(symbol OperatorSymbol) string {
+
+	if symbol == EQ {
+		return "=="
+	}
+	return symbol.String()
+}
+
+/*
+	Formats the arguments of a function or method, which are usually within parenthesis already.
+*/
+func formatArguments(stage *evaluationStage) string {
+
+	if stage == nil {
+		return "()"
+	}
+
+	if stage.symbol == NOOP {
+		return formatStage(stage)
+	}
+	return "(" + formatStage(stage) + ")"
+}
+
+/*
+	Formats the body of a lambda or let binding, which is wrapped in a noop that doesn't represent parenthesis.
+*/
+func formatBody(stage *evaluationStage) string {
+
+	if stage != nil && stage.symbol == NOOP {
+		return formatStage(stage.rightStage)
+	}
+	return formatStage(stage)
+}
+
+func formatLiteral(value interface{}) string {
+
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return quoteString(value)
+	case float64:
+		// whole numbers are written in full, unless they're so large that it would be unclear.
+		if value == math.Trunc(value) && math.Abs(value) < 1e15 {
+			return strconv.FormatFloat(value, 'f', -1, 64)
+		}
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	case *regexp.Regexp:
+		return quoteString(value.String())
+	case time.Time:
+		return quoteString(value.Format(time.RFC3339Nano))
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+/*
+	Quotes the given [value] in single quotes, escaping it the same way Go would.
+*/
+func quoteString(value string) string {
+
+	quoted := strconv.Quote(value)
+	quoted = strings.Replace(quoted[1:len(quoted)-1], `\"`, `"`, -1)
+	return "'" + strings.Replace(quoted, "'", `\'`, -1) + "'"
+}
+
+/*
+	Formats the name of a parameter, escaping it in brackets if it wouldn't otherwise be read as a name.
+*/
+func formatName(name string) string {
+
+	switch name {
+	case "true", "false", "null", "in", "IN", "let", "":
+		return "[" + name + "]"
+	}
+
+	for i, character := range name {
+		if !unicode.IsLetter(character) && (i == 0 || (!unicode.IsDigit(character) && character != '_')) {
+			return "[" + name + "]"
+		}
+	}
+	return name
+}
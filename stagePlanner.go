@@ -3,6 +3,7 @@ package govaluate
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -34,7 +35,7 @@ var stageSymbolMap = map[OperatorSymbol]evaluationOperator{
 	BITWISE_NOT:    bitwiseNotStage,
 	TERNARY_TRUE:   ternaryIfStage,
 	TERNARY_FALSE:  ternaryElseStage,
-	COALESCE:       ternaryElseStage,
+	COALESCE:       coalesceStage,
 	SEPARATE:       separatorStage,
 }
 
@@ -140,7 +141,7 @@ func init() {
 	planSeparator = makePrecedentFromPlanner(&precedencePlanner{
 		validSymbols: separatorSymbols,
 		validKinds:   []TokenKind{SEPARATOR},
-		next:         planTernary,
+		next:         planLambda,
 	})
 }
 
@@ -178,9 +179,10 @@ func makePrecedentFromPlanner(planner *precedencePlanner) precedent {
 	which is used to completely evaluate a set of tokens at evaluation-time.
 	The three stages of evaluation can be thought of as parsing strings to tokens, then tokens to a stage list, then evaluation with parameters.
 */
-func planStages(tokens []ExpressionToken) (*evaluationStage, error) {
+func planStages(tokens []ExpressionToken, functionNames map[int]string) (*evaluationStage, error) {
 
 	stream := newTokenStream(tokens)
+	stream.functionNames = functionNames
 
 	stage, err := planTokens(stream)
 	if err != nil {
@@ -190,8 +192,10 @@ func planStages(tokens []ExpressionToken) (*evaluationStage, error) {
 	// while we're now fully-planned, we now need to re-order same-precedence operators.
 	// this could probably be avoided with a different planning method
 	reorderStages(stage)
+	linkSeparators(stage)
 
 	stage = elideLiterals(stage)
+	linkTernaries(stage)
 	return stage, nil
 }
 
@@ -200,7 +204,51 @@ func planTokens(stream *tokenStream) (*evaluationStage, error) {
 		return nil, nil
 	}
 
-	return planSeparator(stream)
+	return planLet(stream)
+}
+
+/*
+	Let bindings evaluate a value once, and make it available by name to the rest of the clause, as in `let a = b * 2; a > 1`.
+	They're the lowest precedence of anything, so the body of a binding extends to the end of the clause it's in.
+*/
+func planLet(stream *tokenStream) (*evaluationStage, error) {
+
+	var token ExpressionToken
+	var value, body *evaluationStage
+	var err error
+
+	token = stream.next()
+
+	if token.Kind != LET {
+		stream.rewind()
+		return planSeparator(stream)
+	}
+
+	value, err = planSeparator(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	// advance past the TERMINATOR token. We know that it's a TERMINATOR, because at parse-time we check for unterminated bindings.
+	stream.next()
+
+	body, err = planLet(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	// the body is wrapped in a noop for the same reason clauses are; so that chained bindings are not reordered.
+	return &evaluationStage{
+
+		symbol:    BIND,
+		binding:   token.Value.(string),
+		leftStage: value,
+		rightStage: &evaluationStage{
+			rightStage: body,
+			operator:   noopStageRight,
+			symbol:     NOOP,
+		},
+	}, nil
 }
 
 /*
@@ -284,6 +332,52 @@ func planPrecedenceLevel(
 	return leftStage, nil
 }
 
+/*
+	Lambdas are a name followed by an arrow and a body, such as `x => x > 1`.
+	They're of lower precedence than anything but separators, so that they can be passed as function arguments
+	without needing to be wrapped in parenthesis.
+*/
+func planLambda(stream *tokenStream) (*evaluationStage, error) {
+
+	var token ExpressionToken
+	var body *evaluationStage
+	var err error
+
+	if !stream.hasNext() {
+		return nil, nil
+	}
+
+	token = stream.next()
+
+	if token.Kind != VARIABLE || !stream.hasNext() {
+		stream.rewind()
+		return planTernary(stream)
+	}
+
+	if stream.next().Kind != LAMBDA {
+		stream.rewind()
+		stream.rewind()
+		return planTernary(stream)
+	}
+
+	body, err = planLambda(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	// the body is wrapped in a noop for the same reason clauses are; so that chained lambdas are not reordered.
+	return &evaluationStage{
+
+		symbol:  CLOSURE,
+		binding: token.Value.(string),
+		rightStage: &evaluationStage{
+			rightStage: body,
+			operator:   noopStageRight,
+			symbol:     NOOP,
+		},
+	}, nil
+}
+
 /*
 	A special case where functions need to be of higher precedence than values, and need a special wrapped execution stage operator.
 */
@@ -300,6 +394,8 @@ func planFunction(stream *tokenStream) (*evaluationStage, error) {
 		return planAccessor(stream)
 	}
 
+	name := stream.functionNames[stream.index-1]
+
 	rightStage, err = planAccessor(stream)
 	if err != nil {
 		return nil, err
@@ -311,6 +407,7 @@ func planFunction(stream *tokenStream) (*evaluationStage, error) {
 		rightStage:      rightStage,
 		operator:        makeFunctionStage(token.Value.(ExpressionFunction)),
 		typeErrorFormat: "Unable to run function '%v': %v",
+		reference:       name,
 	}, nil
 }
 
@@ -341,7 +438,8 @@ func planAccessor(stream *tokenStream) (*evaluationStage, error) {
 
 			stream.rewind()
 
-			rightStage, err = planTokens(stream)
+			// only the arguments belong to the method, not whatever follows them.
+			rightStage, err = planValue(stream)
 			if err != nil {
 				return nil, err
 			}
@@ -356,6 +454,7 @@ func planAccessor(stream *tokenStream) (*evaluationStage, error) {
 		rightStage:      rightStage,
 		operator:        makeAccessorStage(token.Value.([]string)),
 		typeErrorFormat: "Unable to access parameter field or method '%v': %v",
+		reference:       strings.Join(token.Value.([]string), "."),
 	}, nil
 }
 
@@ -414,6 +513,9 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 	case TIME:
 		symbol = LITERAL
 		operator = makeLiteralStage(float64(token.Value.(time.Time).Unix()))
+	case NULL:
+		symbol = LITERAL
+		operator = makeLiteralStage(nil)
 
 	case PREFIX:
 		stream.rewind()
@@ -425,10 +527,15 @@ func planValue(stream *tokenStream) (*evaluationStage, error) {
 		return nil, errors.New(errorMsg)
 	}
 
-	return &evaluationStage{
+	ret = &evaluationStage{
 		symbol:   symbol,
 		operator: operator,
-	}, nil
+	}
+
+	if token.Kind == VARIABLE {
+		ret.reference = token.Value.(string)
+	}
+	return ret, nil
 }
 
 /*
@@ -549,6 +656,60 @@ func reorderStages(rootStage *evaluationStage) {
 	}
 }
 
+/*
+	Once reordered, a list like `1, 2, 3` is a chain of separator stages leaning left, each adding one more value to the array made by the last.
+	Only separators whose left side is another separator may append to it; any other array on the left (such as an array parameter)
+	is a single element of a new array, rather than something to be flattened.
+*/
+func linkSeparators(stage *evaluationStage) {
+
+	if stage == nil {
+		return
+	}
+
+	if stage.symbol == SEPARATE && stage.leftStage != nil && stage.leftStage.symbol == SEPARATE {
+		stage.operator = appendSeparatorStage
+	}
+
+	linkSeparators(stage.leftStage)
+	linkSeparators(stage.rightStage)
+}
+
+/*
+	Once simplified, the `?` of every ternary which has an else (`a ? b : c`) is made to evaluate to `ternaryUnmatched`
+	rather than nil when its condition is false, so that the else is only evaluated then, and not whenever `b` is null.
+*/
+func linkTernaries(stage *evaluationStage) {
+
+	if stage == nil {
+		return
+	}
+
+	condition := findTernaryCondition(stage)
+	if condition != nil {
+		condition.operator = ternaryConditionStage
+	}
+
+	linkTernaries(stage.leftStage)
+	linkTernaries(stage.rightStage)
+}
+
+/*
+	Returns the `a ? b` of the given [stage], if it's the else of a ternary `a ? b : c`. Otherwise, returns nil.
+*/
+func findTernaryCondition(stage *evaluationStage) *evaluationStage {
+
+	if stage.symbol != TERNARY_FALSE {
+		return nil
+	}
+
+	condition := unwrapNoop(stage.leftStage)
+	if condition == nil || condition.symbol != TERNARY_TRUE {
+		return nil
+	}
+	return condition
+}
+
 /*
 	Performs a "mirror" on a subtree of stages.
 	This mirror functionally inverts the order of execution for all members of the [stages] list.
@@ -589,10 +750,30 @@ func mirrorStageSubtree(stages []*evaluationStage) {
 }
 
 /*
-	Recurses through all operators in the entire tree, eliding operators where both sides are literals.
+	Recurses through all operators in the entire tree, eliding operators where both sides are literals,
+	and simplifying those which can be (see `simplifyStage`).
 */
 func elideLiterals(root *evaluationStage) *evaluationStage {
 
+	// only a list written as a single literal, like `('a')`, not anything else in parenthesis which folds into one, like `(1 * 1)`.
+	list := root.symbol == IN && root.rightStage != nil && root.rightStage.symbol == NOOP &&
+		root.rightStage.rightStage != nil && root.rightStage.rightStage.symbol == LITERAL
+
+	// the condition of a ternary with an else isn't simplified on its own, since it evaluates to something else without one.
+	condition := findTernaryCondition(root)
+	if condition != nil {
+
+		if condition.leftStage != nil {
+			condition.leftStage = elideLiterals(condition.leftStage)
+		}
+		if condition.rightStage != nil {
+			condition.rightStage = elideLiterals(condition.rightStage)
+		}
+
+		root.rightStage = elideLiterals(root.rightStage)
+		return simplifyStage(root)
+	}
+
 	if root.leftStage != nil {
 		root.leftStage = elideLiterals(root.leftStage)
 	}
@@ -601,7 +782,16 @@ func elideLiterals(root *evaluationStage) *evaluationStage {
 		root.rightStage = elideLiterals(root.rightStage)
 	}
 
-	return elideStage(root)
+	// a list of one literal, like `x in ('a')`, is elided to just the literal, which `in` would fail with; it's an equality instead.
+	if list && root.rightStage.symbol == LITERAL {
+		root = makeStage(EQ, root.leftStage, root.rightStage, comparatorErrorFormat)
+	}
+
+	root = elideStage(root)
+	if root.symbol == LITERAL {
+		return root
+	}
+	return simplifyStage(root)
 }
 
 /*
@@ -614,6 +804,11 @@ func elideStage(root *evaluationStage) *evaluationStage {
 	var leftValue, rightValue, result interface{}
 	var err error
 
+	// parenthesis around a single value are no longer needed once stages have been reordered.
+	if root.symbol == NOOP && root.leftStage == nil && root.rightStage != nil && root.rightStage.symbol == LITERAL {
+		return root.rightStage
+	}
+
 	// right side must be a non-nil value. Left side must be nil or a value.
 	if root.rightStage == nil ||
 		root.rightStage.symbol != LITERAL ||
@@ -624,7 +819,7 @@ func elideStage(root *evaluationStage) *evaluationStage {
 
 	// don't elide some operators
 	switch root.symbol {
-	case SEPARATE, IN:
+	case SEPARATE, IN, BIND:
 		return root
 	}
 
@@ -0,0 +1,342 @@
+package govaluate
+
+import "math"
+
+/*
+	The type which a stage is known to evaluate to (if it doesn't fail), regardless of parameters.
+*/
+type stageType int
+
+const (
+	unknownType stageType = iota
+	boolType
+	numberType
+	stringType
+)
+
+/*
+	Rewrites the given [root] stage into a simpler one which gives the same result, if it can. Expects its children to have been simplified already.
+
+	Rewrites are only made where they can't change the result, nor whether evaluation fails (or the error it fails with).
+	So an operand is only removed if it would have been skipped anyway, or if it's known to be of the type the operator checks for;
+	`x && true` becomes `x` only if `x` is known to be a bool (such as a comparison), since otherwise it would fail the type check.
+*/
+func simplifyStage(root *evaluationStage) *evaluationStage {
+
+	left := unwrapNoop(root.leftStage)
+	right := unwrapNoop(root.rightStage)
+
+	switch root.symbol {
+
+	case AND, OR:
+		// `false && x` and `true || x` never evaluate x.
+		shortCircuit := root.symbol == OR
+		if isLiteralValue(left, shortCircuit) {
+			return left
+		}
+
+		// `true && x` and `false || x` are x, as long as it's a bool.
+		if isLiteralValue(left, !shortCircuit) && findStageType(right) == boolType {
+			return root.rightStage
+		}
+		if isLiteralValue(right, !shortCircuit) && findStageType(left) == boolType {
+			return root.leftStage
+		}
+
+	case INVERT:
+		if right == nil {
+			break
+		}
+
+		switch right.symbol {
+		case LITERAL:
+			return foldPrefix(root)
+		case INVERT:
+			if findStageType(unwrapNoop(right.rightStage)) == boolType {
+				return right.rightStage
+			}
+		case EQ, NEQ, REQ, NREQ:
+			return invertComparator(right)
+		}
+
+	case NEGATE, BITWISE_NOT:
+		if right != nil && right.symbol == LITERAL {
+			return foldPrefix(root)
+		}
+
+	case PLUS:
+		// `x + -0` is x if it's a number, and not being concatenated to "x0". `x + 0` isn't, since -0 + 0 is 0.
+		if isSignedZero(right, true) && findStageType(left) == numberType {
+			return root.leftStage
+		}
+		if isSignedZero(left, true) && findStageType(right) == numberType {
+			return root.rightStage
+		}
+		return foldChain(root)
+
+	case MINUS:
+		if isSignedZero(right, false) && findStageType(left) == numberType {
+			return root.leftStage
+		}
+
+	case MULTIPLY:
+		if isLiteralValue(right, 1.0) && findStageType(left) == numberType {
+			return root.leftStage
+		}
+		if isLiteralValue(left, 1.0) && findStageType(right) == numberType {
+			return root.rightStage
+		}
+		return foldChain(root)
+
+	case DIVIDE:
+		if isLiteralValue(right, 1.0) && findStageType(left) == numberType {
+			return root.leftStage
+		}
+
+	case TERNARY_TRUE:
+		// `false ? x` is null without evaluating x, and `true ? x` is x. Those with an else are simplified along with it.
+		if isLiteralValue(left, false) {
+			return makeLiteral(nil)
+		}
+		if isLiteralValue(left, true) {
+			return root.rightStage
+		}
+
+	case TERNARY_FALSE, COALESCE:
+		// `true ? x : y` is x (even if it's null), and `false ? x : y` is y.
+		condition := findTernaryCondition(root)
+		if condition != nil {
+
+			test := unwrapNoop(condition.leftStage)
+			if isLiteralValue(test, true) {
+				return condition.rightStage
+			}
+			if isLiteralValue(test, false) {
+				return root.rightStage
+			}
+			break
+		}
+
+		// the right side is only evaluated if the left is null.
+		if isLiteralValue(left, nil) {
+			return root.rightStage
+		}
+		if isNeverNil(left) {
+			return root.leftStage
+		}
+	}
+
+	return root
+}
+
+/*
+	Returns the type that the given [stage] is known to evaluate to, or `unknownType` if it depends on parameters.
+*/
+func findStageType(stage *evaluationStage) stageType {
+
+	stage = unwrapNoop(stage)
+	if stage == nil {
+		return unknownType
+	}
+
+	switch stage.symbol {
+
+	case LITERAL:
+		value, err := stage.operator(nil, nil, nil)
+		if err != nil {
+			return unknownType
+		}
+
+		switch value.(type) {
+		case bool:
+			return boolType
+		case float64:
+			return numberType
+		case string:
+			return stringType
+		}
+
+	case AND, OR, INVERT, EQ, NEQ, GT, LT, GTE, LTE, REQ, NREQ, IN:
+		return boolType
+
+	case MINUS, MULTIPLY, DIVIDE, MODULUS, EXPONENT, NEGATE,
+		BITWISE_AND, BITWISE_OR, BITWISE_XOR, BITWISE_NOT, BITWISE_LSHIFT, BITWISE_RSHIFT:
+		return numberType
+
+	case PLUS:
+		left := findStageType(stage.leftStage)
+		right := findStageType(stage.rightStage)
+
+		if left == stringType || right == stringType {
+			return stringType
+		}
+		if left == numberType && right == numberType {
+			return numberType
+		}
+	}
+	return unknownType
+}
+
+/*
+	Whether the given [stage] never evaluates to nil (unless it fails).
+*/
+func isNeverNil(stage *evaluationStage) bool {
+	return findStageType(stage) != unknownType || (stage != nil && stage.symbol == LITERAL && !isLiteralValue(stage, nil))
+}
+
+/*
+	Whether the given [stage] is a literal zero with the given sign; -0 if [negative], otherwise 0.
+*/
+func isSignedZero(stage *evaluationStage, negative bool) bool {
+
+	if !isLiteralValue(stage, 0.0) {
+		return false
+	}
+
+	value, _ := stage.operator(nil, nil, nil)
+	return math.Signbit(value.(float64)) == negative
+}
+
+/*
+	Whether the given [stage] is a literal of the given [value].
+*/
+func isLiteralValue(stage *evaluationStage, value interface{}) bool {
+
+	if stage == nil || stage.symbol != LITERAL {
+		return false
+	}
+
+	literal, err := stage.operator(nil, nil, nil)
+	return err == nil && literal == value
+}
+
+/*
+	Evaluates a prefix on a literal, such as `-1`. If the literal is the wrong type for it, it's left to fail when evaluated.
+*/
+func foldPrefix(root *evaluationStage) *evaluationStage {
+
+	value, err := unwrapNoop(root.rightStage).operator(nil, nil, nil)
+	if err != nil || typeCheck(root.rightTypeCheck, value, root.symbol, root.typeErrorFormat) != nil {
+		return root
+	}
+
+	result, err := root.operator(nil, value, nil)
+	if err != nil {
+		return root
+	}
+	return makeLiteral(result)
+}
+
+/*
+	Turns around the given comparison, for a `!` in front of it. Only comparisons whose inverse is exact are given;
+	`!(a < b)` isn't the same as `a >= b` for NaN.
+*/
+func invertComparator(comparison *evaluationStage) *evaluationStage {
+
+	inverses := map[OperatorSymbol]OperatorSymbol{
+		EQ:   NEQ,
+		NEQ:  EQ,
+		REQ:  NREQ,
+		NREQ: REQ,
+	}
+
+	return makeStage(inverses[comparison.symbol], comparison.leftStage, comparison.rightStage, comparison.typeErrorFormat)
+}
+
+/*
+	Folds the literals of a chain into one, where that evaluates to exactly the same thing.
+	Strings at the end of a chain like `x + 'a' + 'b'` are folded into `x + 'ab'`, which is concatenated the same either way.
+	Numbers are only folded in products like `2 * x * 4`, when both literals are powers of two of at least 1 (or -1),
+	which only change the exponent of what they multiply; so `x * 8` is rounded, and overflows, the same way.
+	Other chains of numbers aren't folded, since regrouping them can change how their results are rounded.
+*/
+func foldChain(root *evaluationStage) *evaluationStage {
+
+	var operand, literal *evaluationStage
+
+	inner := unwrapNoop(root.leftStage)
+	right := unwrapNoop(root.rightStage)
+
+	if inner == nil || inner.symbol != root.symbol || right == nil || right.symbol != LITERAL {
+		return root
+	}
+
+	innerLeft := unwrapNoop(inner.leftStage)
+	innerRight := unwrapNoop(inner.rightStage)
+
+	switch {
+	case innerRight != nil && innerRight.symbol == LITERAL:
+		operand, literal = inner.leftStage, innerRight
+	case innerLeft != nil && innerLeft.symbol == LITERAL && root.symbol == MULTIPLY:
+		operand, literal = inner.rightStage, innerLeft
+	default:
+		return root
+	}
+
+	switch root.symbol {
+
+	// the operand fails the type check of `*` the same way, whichever literal it's multiplied by.
+	case MULTIPLY:
+		if !isScalingPowerOfTwo(literal) || !isScalingPowerOfTwo(right) {
+			return root
+		}
+
+	case PLUS:
+		if findStageType(literal) != stringType || findStageType(right) != stringType {
+			return root
+		}
+	}
+
+	folded := *root
+	folded.leftStage = literal
+	folded.rightStage = right
+
+	combined := elideStage(&folded)
+	if combined.symbol != LITERAL {
+		return root
+	}
+
+	return makeStage(root.symbol, operand, combined, root.typeErrorFormat)
+}
+
+/*
+	Whether the given [stage] is a literal power of two, or its negative, which is at least 1 (or at most -1).
+*/
+func isScalingPowerOfTwo(stage *evaluationStage) bool {
+
+	if findStageType(stage) != numberType || stage.symbol != LITERAL {
+		return false
+	}
+
+	value, _ := stage.operator(nil, nil, nil)
+	fraction, exponent := math.Frexp(math.Abs(value.(float64)))
+
+	return fraction == 0.5 && exponent >= 1
+}
+
+func makeLiteral(value interface{}) *evaluationStage {
+	return &evaluationStage{
+		symbol:   LITERAL,
+		operator: makeLiteralStage(value),
+	}
+}
+
+/*
+	Makes a stage for the given operator [symbol], as the planner would.
+*/
+func makeStage(symbol OperatorSymbol, left, right *evaluationStage, typeErrorFormat string) *evaluationStage {
+
+	checks := findTypeChecks(symbol)
+
+	return &evaluationStage{
+		symbol:     symbol,
+		leftStage:  left,
+		rightStage: right,
+		operator:   stageSymbolMap[symbol],
+
+		leftTypeCheck:   checks.left,
+		rightTypeCheck:  checks.right,
+		typeCheck:       checks.combined,
+		typeErrorFormat: typeErrorFormat,
+	}
+}
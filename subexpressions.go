@@ -0,0 +1,155 @@
+package govaluate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+/*
+	Finds the stages within the given [roots] which are identical to one another, and gives each group of them a cache slot,
+	so that they're only evaluated once per evaluation. Slots are numbered from [firstSlot] (which counts from zero),
+	and the number of slots used is returned.
+
+	Only stages which always give the same result for the same parameters are shared. Functions are only shared if [pure]
+	returns true for their name. Method calls (which may have side effects), and anything within a lambda or
+	a let binding's body (where names may mean something else) are never shared. Since an accessor without arguments
+	may turn out to be either a field or a method, its result is only kept if it didn't call a method.
+*/
+func shareSubexpressions(roots []*evaluationStage, firstSlot int, pure func(string) bool) int {
+
+	finder := subexpressionFinder{
+		stages: make(map[string][]*evaluationStage),
+		pure:   pure,
+	}
+
+	for _, root := range roots {
+		finder.find(root)
+	}
+
+	slot := firstSlot
+	for _, key := range finder.keys {
+
+		stages := finder.stages[key]
+		if len(stages) < 2 {
+			continue
+		}
+
+		slot++
+		for _, stage := range stages {
+			stage.cacheSlot = slot
+		}
+	}
+	return slot - firstSlot
+}
+
+type subexpressionFinder struct {
+
+	// every stage which may be shared, by its key.
+	stages map[string][]*evaluationStage
+
+	// the keys of [stages], in the order they were found, so that slots are numbered the same every time.
+	keys []string
+
+	// whether the function of a given name can be shared.
+	pure func(string) bool
+}
+
+/*
+	Returns a key which is the same for every stage that's structurally identical to the given [stage],
+	and records the stage under that key. Returns false if the stage can't be shared.
+*/
+func (f *subexpressionFinder) find(stage *evaluationStage) (string, bool) {
+
+	var key string
+
+	if stage == nil {
+		return "", true
+	}
+
+	switch stage.symbol {
+	case CLOSURE:
+		return "", false
+	case BIND:
+		f.find(stage.leftStage)
+		return "", false
+	}
+
+	var left string
+	var leftShared bool
+
+	// the condition of a ternary with an else evaluates to something other than it would alone,
+	// so it's only shared as part of the whole ternary.
+	condition := findTernaryCondition(stage)
+	if condition != nil {
+
+		test, testShared := f.find(condition.leftStage)
+		value, valueShared := f.find(condition.rightStage)
+
+		left = fmt.Sprintf("%d(%s,%s)", condition.symbol, test, value)
+		leftShared = testShared && valueShared
+	} else {
+		left, leftShared = f.find(stage.leftStage)
+	}
+
+	right, rightShared := f.find(stage.rightStage)
+
+	if !leftShared || !rightShared {
+		return "", false
+	}
+
+	switch stage.symbol {
+
+	case FUNCTIONAL:
+		if stage.reference == "" || !f.pure(stage.reference) {
+			return "", false
+		}
+		key = "@" + stage.reference + "(" + right + ")"
+
+	// literals are cheaper to evaluate than to look up, so they're only part of the keys of other stages.
+	case LITERAL:
+		value, err := stage.operator(nil, nil, nil)
+		if err != nil {
+			return "", false
+		}
+		return literalKey(value), true
+
+	case ACCESS:
+		if stage.rightStage != nil {
+			return "", false
+		}
+		key = "." + stage.reference
+
+	case VALUE:
+		if stage.reference == "" {
+			return "", false
+		}
+		key = "$" + stage.reference
+
+	default:
+		key = fmt.Sprintf("%d(%s,%s)", stage.symbol, left, right)
+	}
+
+	// parenthesis are shared by what's within them.
+	if stage.symbol == NOOP {
+		return key, true
+	}
+
+	if _, found := f.stages[key]; !found {
+		f.keys = append(f.keys, key)
+	}
+
+	f.stages[key] = append(f.stages[key], stage)
+	return key, true
+}
+
+func literalKey(value interface{}) string {
+
+	switch value := value.(type) {
+	case string:
+		return strconv.Quote(value)
+	case *regexp.Regexp:
+		return "pattern " + strconv.Quote(value.String())
+	}
+	return fmt.Sprintf("%T(%v)", value, value)
+}
@@ -24,6 +24,10 @@ func TestTokenKindStrings(test *testing.T) {
 		CLAUSE,
 		CLAUSE_CLOSE,
 		TERNARY,
+		LAMBDA,
+		LET,
+		TERMINATOR,
+		NULL,
 	}
 
 	kindStrings := make(map[string]struct{}, len(kinds))
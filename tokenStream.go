@@ -4,6 +4,9 @@ type tokenStream struct {
 	tokens      []ExpressionToken
 	index       int
 	tokenLength int
+
+	// the names of the functions used by the tokens, by the index of their token.
+	functionNames map[int]string
 }
 
 func newTokenStream(tokens []ExpressionToken) *tokenStream {
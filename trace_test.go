@@ -0,0 +1,131 @@
+package govaluate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEvalWithTrace(test *testing.T) {
+
+	expression, err := NewEvaluableExpression("age > 18 && plan == 'pro' || any(items, x => x > limit)")
+	if err != nil {
+		test.Logf("Failed to parse expression: %v", err)
+		test.FailNow()
+	}
+
+	result, trace, err := expression.EvalWithTrace(MapParameters{
+		"age":   17,
+		"plan":  "pro",
+		"items": []interface{}{1, 5},
+		"limit": 3,
+	})
+	if err != nil || result != true {
+		test.Logf("Expected true, got %v (error %v)", result, err)
+		test.FailNow()
+	}
+
+	expected := strings.Join([]string{
+		"age > 18 && plan == 'pro' || any(items, x => x > limit) → true",
+		"  age > 18 && plan == 'pro' → false",
+		"    age > 18 → false because age = 17",
+		"    plan == 'pro' (short-circuited)",
+		"  any(items, x => x > limit) → true because items = (1, 5)",
+		"    x > limit → false because x = 1, limit = 3",
+		"    x > limit → true because x = 5, limit = 3",
+		"",
+	}, "\n")
+
+	if trace.String() != expected {
+		test.Logf("Expected trace:\n%s\ngot:\n%s", expected, trace.String())
+		test.Fail()
+	}
+
+	comparison := trace.Children[0].Children[0]
+	if comparison.Operator != ">" || comparison.Left != 17.0 || comparison.Right != 18.0 || comparison.Result != false {
+		test.Logf("Expected the operands and result of 'age > 18', got %+v", comparison)
+		test.Fail()
+	}
+
+	encoded, err := json.Marshal(trace.Children[0].Children[1])
+	if err != nil || string(encoded) != `{"expression":"plan == 'pro'","operator":"==","result":null,"shortCircuited":true}` {
+		test.Logf("Unexpected JSON for a short-circuited node: %s (error %v)", encoded, err)
+		test.Fail()
+	}
+}
+
+func TestEvalWithTraceFailure(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("a > 1 ? missing : 0")
+
+	_, trace, err := expression.EvalWithTrace(MapParameters{"a": 2})
+	if err == nil {
+		test.Logf("Expected a missing parameter to fail")
+		test.FailNow()
+	}
+
+	expected := "No parameter 'missing' found."
+	if trace == nil || !strings.Contains(trace.String(), "  a > 1 ? missing → error: "+expected) {
+		test.Logf("Expected the trace to show where evaluation failed, got:\n%v", trace)
+		test.Fail()
+	}
+}
+
+func TestEvalWithTraceTernary(test *testing.T) {
+
+	expression, _ := NewEvaluableExpression("a ? b : 1")
+
+	result, trace, err := expression.EvalWithTrace(MapParameters{"a": false, "b": 2})
+	if err != nil || result != 1.0 {
+		test.Logf("Expected 1, got %v (error %v)", result, err)
+		test.FailNow()
+	}
+
+	// the condition is shown as null when it's false, as it would be without an else.
+	if !strings.Contains(trace.String(), "  a ? b → null because a = false") || trace.Children[0].Result != nil {
+		test.Logf("Expected the condition of the ternary to be null, got:\n%v", trace)
+		test.Fail()
+	}
+}
+
+/*
+	Tests that planned stages are written back out as expressions which plan into the same stages.
+*/
+func TestStageFormat(test *testing.T) {
+
+	testCases := []struct {
+		Input    string
+		Expected string
+	}{
+		{Input: "a ? b : c ?? d", Expected: "a ? b : c ?? d"},
+		{Input: "x IN (\"a\", 'b', 3)", Expected: "x in ('a', 'b', 3)"},
+		{Input: "-(1 + x) * 2 >= 1e6", Expected: "-(1 + x) * 2 >= 1000000"},
+		{Input: "!foo.Bar?.Baz && foo.Dunk('x') == \"it's\"", Expected: "!foo.Bar?.Baz && foo.Dunk('x') == 'it\\'s'"},
+		{Input: "let a = 2; any(xs, x => x > [weird name]) && [true]", Expected: "let a = 2; any(xs, x => x > [weird name]) && [true]"},
+		{Input: "~x | 3 ** 2 =~ 'a+'", Expected: "~x | 9 =~ 'a+'"},
+		{Input: "'2014-01-02T00:00:00Z' < now", Expected: "1388620800 < now"},
+	}
+
+	for _, testCase := range testCases {
+
+		expression, err := NewEvaluableExpression(testCase.Input)
+		if err != nil {
+			test.Logf("Failed to parse '%s': %v", testCase.Input, err)
+			test.Fail()
+			continue
+		}
+
+		actual := formatStage(expression.evaluationStages)
+		if actual != testCase.Expected {
+			test.Logf("Expected '%s' to be formatted as '%s', got '%s'", testCase.Input, testCase.Expected, actual)
+			test.Fail()
+			continue
+		}
+
+		reparsed, err := NewEvaluableExpression(actual)
+		if err != nil || formatStage(reparsed.evaluationStages) != actual {
+			test.Logf("Expected '%s' to parse into the same stages (error %v)", actual, err)
+			test.Fail()
+		}
+	}
+}